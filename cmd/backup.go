@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/backup"
+	"github.com/arnac-io/history-sanitizer/pkg/safefile"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupRestoreOutput string
+	backupPurgeAll      bool
+	backupPurgeKeep     int
+	backupPurgeMaxAge   string
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Manage backups taken before in-place sanitization",
+	Long: `Every run with --in-place first stores a timestamped backup of the history
+file in the backups directory of the config directory. Backups can be
+listed, restored and purged with the subcommands below.`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List backups, newest first",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runBackupList,
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Restore a history file from a backup",
+	Long: `Write the contents of a backup back to the history file it was taken from,
+or to --output. The current history file is backed up first, so a restore
+can itself be undone.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runBackupRestore,
+}
+
+var backupPurgeCmd = &cobra.Command{
+	Use:   "purge [id...]",
+	Short: "Delete backups",
+	Long: `Delete the given backups, every backup with --all, or the backups outside
+a retention policy with --keep and --max-age.`,
+	SilenceUsage: true,
+	RunE:         runBackupPurge,
+}
+
+func init() {
+	backupRestoreCmd.Flags().StringVarP(&backupRestoreOutput, "output", "o", "", "Write the backup here instead of to its history file")
+	backupPurgeCmd.Flags().BoolVar(&backupPurgeAll, "all", false, "Delete every backup")
+	backupPurgeCmd.Flags().IntVar(&backupPurgeKeep, "keep", 0, "Keep only this many recent backups per history file")
+	backupPurgeCmd.Flags().StringVar(&backupPurgeMaxAge, "max-age", "", "Delete backups older than this (e.g. 30d, 12h)")
+
+	backupCmd.AddCommand(backupListCmd, backupRestoreCmd, backupPurgeCmd)
+	rootCmd.AddCommand(backupCmd)
+}
+
+func runBackupList(cmd *cobra.Command, args []string) error {
+	store, err := backup.DefaultStore()
+	if err != nil {
+		return err
+	}
+	backups, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		fmt.Println(color.New(color.FgYellow).Sprint("No backups found in " + store.Dir))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tSIZE\tENCRYPTED\tSOURCE")
+	for _, b := range backups {
+		encrypted := "no"
+		if b.Encrypted {
+			encrypted = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", b.ID, b.Created.Local().Format(time.DateTime), b.Size, encrypted, b.Source)
+	}
+	return w.Flush()
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) error {
+	green := color.New(color.FgGreen).SprintFunc()
+
+	store, err := backup.DefaultStore()
+	if err != nil {
+		return err
+	}
+	b, err := store.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	var pass []byte
+	if b.Encrypted {
+		if pass, err = readPassphrase(false); err != nil {
+			return err
+		}
+	}
+	data, err := store.Read(b, pass)
+	if err != nil {
+		return err
+	}
+
+	target := backupRestoreOutput
+	if target == "" {
+		target = b.Source
+	}
+
+	if current, err := os.ReadFile(target); err == nil {
+		locked, unlock, err := safefile.Lock(target)
+		if err != nil {
+			return fmt.Errorf("failed to lock %s: %w", target, err)
+		}
+		defer unlock()
+
+		// Re-read under the lock so nothing appended meanwhile is lost
+		if current, err = readLocked(locked); err != nil {
+			return err
+		}
+		saved, err := store.Create(target, current, pass)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s Current %s backed up as %s\n", green("✓"), target, saved.ID)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := safefile.WriteFile(target, data, 0600); err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+	fmt.Printf("%s Restored backup %s to %s\n", green("✓"), b.ID, green(target))
+	return nil
+}
+
+func runBackupPurge(cmd *cobra.Command, args []string) error {
+	green := color.New(color.FgGreen).SprintFunc()
+
+	store, err := backup.DefaultStore()
+	if err != nil {
+		return err
+	}
+
+	var removed []backup.Backup
+	switch {
+	case len(args) > 0:
+		for _, id := range args {
+			b, err := store.Get(id)
+			if err != nil {
+				return err
+			}
+			if err := store.Remove(b); err != nil {
+				return fmt.Errorf("failed to remove backup %s: %w", id, err)
+			}
+			removed = append(removed, b)
+		}
+	case backupPurgeAll:
+		backups, err := store.List()
+		if err != nil {
+			return err
+		}
+		for _, b := range backups {
+			if err := store.Remove(b); err != nil {
+				return fmt.Errorf("failed to remove backup %s: %w", b.ID, err)
+			}
+			removed = append(removed, b)
+		}
+	case backupPurgeKeep > 0 || backupPurgeMaxAge != "":
+		maxAge, err := backup.ParseAge(backupPurgeMaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid --max-age: %w", err)
+		}
+		if removed, err = store.Prune(backup.Retention{Keep: backupPurgeKeep, MaxAge: maxAge}); err != nil {
+			return err
+		}
+	default:
+		return errors.New("specify backup IDs, --all, or a retention with --keep/--max-age")
+	}
+
+	for _, b := range removed {
+		fmt.Printf("%s Deleted backup %s (%s)\n", green("✓"), b.ID, b.Source)
+	}
+	if len(removed) == 0 {
+		fmt.Println("No backups deleted")
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arnac-io/history-sanitizer/pkg/baseline"
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/report"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	baselineFiles  []string
+	baselineOutput string
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Manage the baseline of acknowledged findings",
+	Long: `A baseline records the fingerprints of acknowledged findings, such as false
+positives, so scans run with --use-baseline, or --baseline <file> for a
+baseline written elsewhere, only report new findings. A fingerprint
+identifies the rule, the secret and the command around it; it still matches
+when the entry moves in the file, but not when the secret or the command
+changes.
+
+Fingerprints are keyed with the placeholder key, so a baseline can only be
+used with the key it was created with. To share it with another machine or
+CI, set HISTORY_SANITIZER_KEY to the same key on both.`,
+}
+
+var baselineCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Acknowledge every current finding in a new baseline",
+	Long: `Scan the history files and record every finding in a baseline file,
+replacing the previous baseline. Review the findings first: anything
+recorded is no longer reported by scans run with --use-baseline.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runBaselineCreate,
+}
+
+func init() {
+	baselineCreateCmd.Flags().StringArrayVarP(&baselineFiles, "file", "f", nil, "History file to scan (repeatable, default: every known history file)")
+	baselineCreateCmd.Flags().StringVarP(&baselineOutput, "output", "o", "", "Baseline file (default: <config dir>/baseline.json)")
+	baselineCreateCmd.Flags().StringVar(&minSeverity, "min-severity", "low", "Only record findings at or above this severity (critical, high, medium, low)")
+	baselineCreateCmd.Flags().StringVar(&shellName, "shell", "auto", "Shell that wrote the history (auto, bash, zsh, fish, powershell)")
+
+	baselineCmd.AddCommand(baselineCreateCmd)
+	rootCmd.AddCommand(baselineCmd)
+}
+
+// addBaselineFlag registers --baseline on a scanning command, and
+// --use-baseline for the baseline in the config directory
+func addBaselineFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&baselineFile, "baseline", "", "Only report findings missing from this baseline file")
+	cmd.Flags().BoolVar(&useBaseline, "use-baseline", false, "Only report findings missing from the baseline in the config directory")
+}
+
+// baselineGiven reports whether a baseline was asked for
+func baselineGiven() bool {
+	return baselineFile != "" || useBaseline
+}
+
+// loadBaseline loads the --baseline file, or the baseline in the config
+// directory for --use-baseline, which must have been created with the
+// placeholder key key
+func loadBaseline(key []byte) (*baseline.Baseline, error) {
+	path := baselineFile
+	if path == "" {
+		var err error
+		if path, err = baseline.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return baseline.Load(path, key)
+}
+
+func runBaselineCreate(cmd *cobra.Command, args []string) error {
+	severity, err := scanner.ParseSeverity(minSeverity)
+	if err != nil {
+		return fmt.Errorf("invalid --min-severity: %w", err)
+	}
+
+	files := baselineFiles
+	if len(files) == 0 {
+		files = existingHistoryFiles()
+		if len(files) == 0 {
+			return fmt.Errorf("no history file found, pass one with --file")
+		}
+	}
+
+	var reports []report.Report
+	var key []byte
+	for _, file := range files {
+		scan, err := scanHistory(file, severity, "", false)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, scan.report)
+		key = scan.placeholder.Key
+		fmt.Printf("%s: %d finding(s)\n", file, len(scan.findings))
+	}
+
+	path := baselineOutput
+	if path == "" {
+		if path, err = baseline.DefaultPath(); err != nil {
+			return err
+		}
+	}
+	b := baseline.New(reports, key)
+	if err := b.Save(path); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Baseline with %d finding(s) written to %s\n", color.GreenString("✓"), len(b.Findings), path)
+	return nil
+}
+
+// existingHistoryFiles returns the known history locations that exist
+func existingHistoryFiles() []string {
+	var files []string
+	for _, path := range history.KnownLocations() {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+	}
+	return files
+}
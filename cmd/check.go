@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/config"
+	"github.com/arnac-io/history-sanitizer/pkg/daemon"
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// daemonTimeout bounds a check by the daemon, after which the command is
+// scanned in process
+const daemonTimeout = 500 * time.Millisecond
+
+var (
+	checkQuiet    bool
+	checkNoDaemon bool
+	// socketPath is the --socket of serve and check
+	socketPath string
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [-- <command>]",
+	Short: "Check a single command line for secrets, for shell hooks and editors",
+	Long: `Scan one command line, given after -- or on stdin, and print it on stdout
+with its secrets redacted, or unchanged when it holds none. Inline hs:allow
+markers and the allowlist are honored. The shell hooks of "init" pipe every
+command to it. Prefer stdin for commands that may hold secrets: other users
+can read the arguments of running processes.
+
+  history-sanitizer check -- curl -u admin:hunter2 https://example.com
+  printf '%s' "$BUFFER" | history-sanitizer check -q
+
+Exit codes:
+  0  no secret found
+  1  secrets found, the redacted command is printed
+  2  error
+
+The command is sent to the serve daemon when it is running, and scanned
+in process otherwise. In process, only the rules whose keywords appear in
+the command are compiled, so a check takes a few milliseconds.`,
+	Args:          cobra.ArbitraryArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runCheck,
+}
+
+func init() {
+	checkCmd.Flags().BoolVarP(&checkQuiet, "quiet", "q", false, "Print nothing, only set the exit code")
+	checkCmd.Flags().StringVar(&minSeverity, "min-severity", "low", "Only redact findings at or above this severity (critical, high, medium, low)")
+	checkCmd.Flags().StringVar(&redactWith, "redact", "placeholder", "Redaction strategy: "+strings.Join(sanitizer.RedactorNames(), ", "))
+	checkCmd.Flags().StringArrayVar(&redactRules, "redact-rule", nil, "Redaction strategy for a rule, as rule=strategy (repeatable)")
+	checkCmd.Flags().StringVar(&shellName, "shell", "auto", "Shell the command is for, for --redact env (auto, bash, zsh, fish, powershell)")
+	checkCmd.Flags().BoolVar(&checkNoDaemon, "no-daemon", false, "Scan in process even when the serve daemon is running")
+	checkCmd.Flags().StringVar(&socketPath, "socket", "", "Socket of the serve daemon (default: <config dir>/daemon.sock)")
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	severity, err := scanner.ParseSeverity(minSeverity)
+	if err != nil {
+		return fmt.Errorf("invalid --min-severity: %w", err)
+	}
+
+	command := strings.Join(args, " ")
+	if len(args) == 0 {
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			return fmt.Errorf("pass the command after -- or on stdin")
+		}
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read command: %w", err)
+		}
+		// The entry is one command, even when it spans several lines
+		command = strings.TrimSuffix(strings.TrimSuffix(string(input), "\n"), "\r")
+	}
+
+	// The daemon has the rules compiled and the config loaded already
+	var redacted string
+	var count int
+	if resp, ok := checkWithDaemon(command); ok {
+		redacted, count = resp.Redacted, len(resp.Findings)
+	} else {
+		var findings []scanner.Finding
+		if redacted, findings, err = checkCommand(command, severity); err != nil {
+			return err
+		}
+		count = len(findings)
+	}
+
+	if !checkQuiet {
+		fmt.Println(redacted)
+	}
+	if count > 0 {
+		return &FindingsError{Count: count, Threshold: severity}
+	}
+	return nil
+}
+
+// checker scans single commands with the key and allowlist of the config
+// directory, loaded once so that serve answers many checks with them
+type checker struct {
+	mu          sync.RWMutex
+	placeholder sanitizer.Placeholder
+	allow       *config.Allowlist
+}
+
+func newChecker() (*checker, error) {
+	c := &checker{}
+	return c, c.reload()
+}
+
+// reload loads the key and allowlist again
+func (c *checker) reload() error {
+	key, err := config.LoadKey()
+	if err != nil {
+		return err
+	}
+	allow, err := config.LoadAllowlist()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.placeholder = sanitizer.Placeholder{Key: key, Length: idLength}
+	c.allow = allow
+	return nil
+}
+
+// scan returns the findings of a command at or above minSeverity, except
+// those allowed by an inline marker or the allowlist
+func (c *checker) scan(command string, minSeverity scanner.Severity) ([]scanner.Finding, error) {
+	allFindings, err := scanner.ScanContent(command)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan command: %w", err)
+	}
+	var findings []scanner.Finding
+	for _, finding := range allFindings {
+		if finding.Severity >= minSeverity {
+			findings = append(findings, finding)
+		}
+	}
+	findings, _ = scanner.FilterAllowed(command, findings)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var kept []scanner.Finding
+	for _, finding := range findings {
+		if !allowlisted(c.allow, c.placeholder, finding) {
+			kept = append(kept, finding)
+		}
+	}
+	return kept, nil
+}
+
+// redact returns a command with its findings redacted, with a strategy
+// and rule=strategy pairs as given to --redact and --redact-rule
+func (c *checker) redact(command string, findings []scanner.Finding, strategy string, rules []string, shell history.Shell) (string, error) {
+	if len(findings) == 0 {
+		return command, nil
+	}
+	c.mu.RLock()
+	placeholder := c.placeholder
+	c.mu.RUnlock()
+
+	opts, err := newRedactionOptions(strategy, rules, placeholder, shell)
+	if err != nil {
+		return "", err
+	}
+	return sanitizer.SanitizeWithOptions(command, findings, opts), nil
+}
+
+// checkCommand scans a single command line in process and returns it with
+// the findings at or above minSeverity redacted
+func checkCommand(command string, minSeverity scanner.Severity) (string, []scanner.Finding, error) {
+	shell, err := history.ParseShell(shellName)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid --shell: %w", err)
+	}
+	c, err := newChecker()
+	if err != nil {
+		return "", nil, err
+	}
+	findings, err := c.scan(command, minSeverity)
+	if err != nil {
+		return "", nil, err
+	}
+	redacted, err := c.redact(command, findings, redactWith, redactRules, shell)
+	if err != nil {
+		return "", nil, err
+	}
+	return redacted, findings, nil
+}
+
+// checkWithDaemon asks the serve daemon to check a command, reporting
+// false when no daemon answered or with --no-daemon
+func checkWithDaemon(command string) (*daemon.Response, bool) {
+	if checkNoDaemon {
+		return nil, false
+	}
+	path := socketPath
+	if path == "" {
+		var err error
+		if path, err = daemon.DefaultSocket(); err != nil {
+			return nil, false
+		}
+	}
+	client, err := daemon.Dial(path, daemonTimeout)
+	if err != nil {
+		return nil, false
+	}
+	defer client.Close()
+
+	resp, err := client.Do(daemon.Request{
+		Op:          daemon.OpSanitize,
+		Command:     command,
+		MinSeverity: minSeverity,
+		Redact:      redactWith,
+		RedactRules: redactRules,
+		Shell:       shellName,
+	})
+	if err != nil {
+		return nil, false
+	}
+	return resp, true
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/arnac-io/history-sanitizer/pkg/checkpoint"
+)
+
+// Checkpoints are kept apart for scans and sanitizations: entries reported
+// by scan have not been sanitized yet
+const (
+	checkpointScan     = "scan"
+	checkpointSanitize = "sanitize"
+)
+
+// incremental is the --incremental flag of the scanning commands
+var incremental bool
+
+// openCheckpoints opens the checkpoint file of the config directory
+func openCheckpoints() (*checkpoint.Store, error) {
+	path, err := checkpoint.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint.Open(path)
+}
+
+// resumeScan returns the offset of content to scan from
+func resumeScan(purpose, path string, content []byte) (int, checkpoint.Status, error) {
+	store, err := openCheckpoints()
+	if err != nil {
+		return 0, checkpoint.StatusNew, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, checkpoint.StatusNew, err
+	}
+	from, status := store.Resume(purpose, path, content, info)
+	return from, status, nil
+}
+
+// recordCheckpoint moves the checkpoint of a history file to the end of
+// content, which must be what the file holds now
+func recordCheckpoint(purpose, path string, content []byte) error {
+	store, err := openCheckpoints()
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	store.Record(purpose, path, content, info)
+	return store.Save()
+}
+
+// printCheckpoint tells how much of the history an incremental scan covered
+func printCheckpoint(out io.Writer, scan *scanResult) {
+	switch scan.status {
+	case checkpoint.StatusResumed:
+		fmt.Fprintf(out, "%d new entries scanned since the last checkpoint\n", scan.entries)
+	case checkpoint.StatusRewritten, checkpoint.StatusTruncated:
+		fmt.Fprintf(out, "History file was rewritten since the last checkpoint, %d entries scanned\n", scan.entries)
+	default:
+		fmt.Fprintf(out, "No checkpoint yet, %d entries scanned\n", scan.entries)
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/arnac-io/history-sanitizer/pkg/diff"
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+	"github.com/fatih/color"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// printDiff prints the unified diff between the history and its sanitized
+// version. Secrets are masked unless --show-secrets is set, including
+// those left in place because they are below --redact-severity.
+func printDiff(out io.Writer, from, to, original, sanitized string, findings []scanner.Finding) {
+	hunks := diff.Hunks(diff.SplitLines(original), diff.SplitLines(sanitized), diffContext)
+	if len(hunks) == 0 {
+		fmt.Fprintln(out, "No changes")
+		return
+	}
+
+	mask := func(s string) string { return s }
+	if !showSecrets {
+		mask = secretMasker(findings).Replace
+	}
+
+	bold := color.New(color.Bold).SprintFunc()
+	fmt.Fprintln(out, bold("--- "+from))
+	fmt.Fprintln(out, bold("+++ "+to))
+	for _, h := range hunks {
+		fmt.Fprintln(out, color.CyanString(h.Header()))
+		for _, l := range h.Lines {
+			switch l.Op {
+			case diff.Delete:
+				fmt.Fprintln(out, color.RedString("-"+mask(l.Text)))
+			case diff.Insert:
+				fmt.Fprintln(out, color.GreenString("+"+mask(l.Text)))
+			default:
+				fmt.Fprintln(out, " "+mask(l.Text))
+			}
+		}
+	}
+	fmt.Fprintln(out)
+}
+
+// secretMasker replaces every secret of the findings with its partially
+// masked form, longest first so a secret containing another is masked whole
+func secretMasker(findings []scanner.Finding) *strings.Replacer {
+	seen := make(map[string]bool)
+	var secrets []scanner.Finding
+	for _, f := range findings {
+		secret := sanitizer.SecretValue(f.Match)
+		if secret == "" || seen[secret] {
+			continue
+		}
+		seen[secret] = true
+		f.Match = secret
+		secrets = append(secrets, f)
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i].Match) > len(secrets[j].Match) })
+
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, f := range secrets {
+		pairs = append(pairs, f.Match, sanitizer.ObfuscatePreview(f.Match, f.Type))
+	}
+	return strings.NewReplacer(pairs...)
+}
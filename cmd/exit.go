@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+)
+
+// Exit codes of history-sanitizer, for scripts and CI to gate on
+const (
+	// ExitClean means the command succeeded and nothing was found above
+	// the failure threshold
+	ExitClean = 0
+	// ExitFindings means a scan found secrets at or above --fail-on
+	ExitFindings = 1
+	// ExitError means the command failed
+	ExitError = 2
+)
+
+// FindingsError is returned when a scan finds secrets at or above the
+// failure threshold
+type FindingsError struct {
+	Count     int
+	Threshold scanner.Severity
+}
+
+func (e *FindingsError) Error() string {
+	return fmt.Sprintf("%d finding(s) at or above %s severity", e.Count, e.Threshold)
+}
+
+// ExitCode returns the process exit code for the error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitClean
+	}
+	var findings *FindingsError
+	if errors.As(err, &findings) {
+		return ExitFindings
+	}
+	return ExitError
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/hook"
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hookMode       string
+	hookRedactWith string
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init <zsh|bash|fish>",
+	Short: "Print the shell hook that keeps secrets out of the history",
+	Long: `Print a snippet that runs "check" on every command line before the shell
+saves it, so secrets never reach the history file. With --mode redact (the
+default) the command is saved with its secrets redacted; with --mode reject
+it is left out of the history.
+
+  zsh   ~/.zshrc                    eval "$(history-sanitizer init zsh)"
+  bash  ~/.bashrc                   eval "$(history-sanitizer init bash)"
+  fish  ~/.config/fish/config.fish  history-sanitizer init fish | source
+
+zsh uses the zshaddhistory hook and fish defines fish_should_add_to_history.
+bash has no such hook: the last entry is checked from PROMPT_COMMAND and
+replaced with history -d, before the history file is written.`,
+	Args:          cobra.ExactArgs(1),
+	ValidArgs:     []string{"zsh", "bash", "fish"},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runInit,
+}
+
+func init() {
+	initCmd.Flags().StringVar(&hookMode, "mode", hook.ModeRedact, "What to do with a command holding secrets: "+hook.ModeRedact+" or "+hook.ModeReject)
+	initCmd.Flags().StringVar(&hookRedactWith, "redact", "", "Redaction strategy of the saved commands: "+strings.Join(sanitizer.RedactorNames(), ", ")+" (default: placeholder)")
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	shell, err := history.ParseShell(args[0])
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(hook.Shells, shell) {
+		return fmt.Errorf("no hook for %s (expected zsh, bash or fish)", args[0])
+	}
+	if hookRedactWith != "" && !slices.Contains(sanitizer.RedactorNames(), hookRedactWith) {
+		return fmt.Errorf("invalid --redact %q (expected %s)", hookRedactWith, strings.Join(sanitizer.RedactorNames(), ", "))
+	}
+
+	// Call the binary by its path, so the hook neither depends on $PATH
+	// nor pays for searching it
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the history-sanitizer binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	command := []string{exe, "check", "--shell", string(shell)}
+	if hookRedactWith != "" {
+		command = append(command, "--redact", hookRedactWith)
+	}
+	script, err := hook.Script(shell, hook.Options{Command: command, Mode: hookMode})
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/arnac-io/history-sanitizer/pkg/config"
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+	"github.com/fatih/color"
+)
+
+// secretHash identifies a secret in the allowlist with the full keyed
+// digest, so the allowlist never holds the secret itself
+func secretHash(p sanitizer.Placeholder, secret string) string {
+	p.Length = 64
+	return p.ID(secret)
+}
+
+// allowlisted reports whether the allowlist keeps a finding
+func allowlisted(allow *config.Allowlist, p sanitizer.Placeholder, finding scanner.Finding) bool {
+	return allow.AllowsRule(finding.Type) || allow.AllowsSecret(secretHash(p, sanitizer.SecretValue(finding.Match)))
+}
+
+// review holds the decisions taken while walking the findings with
+// --interactive
+type review struct {
+	in          *bufio.Reader
+	content     []string
+	placeholder sanitizer.Placeholder
+	shell       history.Shell
+	opts        *sanitizer.Options
+	allow       *config.Allowlist
+	// skipped are the rules skipped for the rest of the review
+	skipped []string
+	// allowedRules and allowedSecrets are the allowlist decisions, offered
+	// for saving at the end
+	allowedRules   []string
+	allowedSecrets []scanner.Finding
+}
+
+// reviewFindings walks the findings one by one, showing the line as it
+// would be sanitized, and returns the findings the user accepted to
+// redact. Strategy changes are recorded in opts. Allowlisted rules and
+// secrets are added to allow and saved to the config directory if the
+// user agrees.
+func reviewFindings(content string, findings []scanner.Finding, opts *sanitizer.Options, p sanitizer.Placeholder, shell history.Shell, allow *config.Allowlist) ([]scanner.Finding, error) {
+	r := &review{
+		in:          bufio.NewReader(os.Stdin),
+		content:     strings.Split(content, "\n"),
+		placeholder: p,
+		shell:       shell,
+		opts:        opts,
+		allow:       allow,
+	}
+
+	var accepted []scanner.Finding
+	for i, finding := range findings {
+		// Findings below --redact-severity are only reported
+		if !opts.ShouldRedact(finding) {
+			continue
+		}
+		if r.keeps(finding) || allowlisted(allow, p, finding) {
+			continue
+		}
+
+		accept, err := r.ask(i+1, len(findings), finding)
+		if errors.Is(err, io.EOF) {
+			fmt.Println()
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if accept {
+			accepted = append(accepted, finding)
+		}
+	}
+
+	if decisions := len(r.allowedRules) + len(r.allowedSecrets); decisions > 0 {
+		path, err := config.AllowlistPath()
+		if err != nil {
+			return nil, err
+		}
+		if r.confirm(fmt.Sprintf("Save %d allowlist decision(s) to %s for future runs?", decisions, path)) {
+			if err := r.saveAllowlist(); err != nil {
+				return nil, err
+			}
+			fmt.Printf("%s Allowlist saved\n", color.GreenString("✓"))
+		}
+	}
+	return accepted, nil
+}
+
+// keeps reports whether a finding was kept for the rest of the review
+func (r *review) keeps(finding scanner.Finding) bool {
+	if slices.Contains(r.skipped, finding.Type) {
+		return true
+	}
+	secret := sanitizer.SecretValue(finding.Match)
+	return slices.ContainsFunc(r.allowedSecrets, func(f scanner.Finding) bool {
+		return sanitizer.SecretValue(f.Match) == secret
+	})
+}
+
+// saveAllowlist adds the allowlist decisions to the allowlist and saves it.
+// Secrets are hashed with the stored placeholder key, created if needed:
+// a --dry-run review only has a throwaway key when none is stored yet, and
+// hashes made with it would never match again.
+func (r *review) saveAllowlist() error {
+	key, err := config.LoadKey()
+	if err != nil {
+		return err
+	}
+	p := r.placeholder
+	p.Key = key
+
+	for _, rule := range r.allowedRules {
+		r.allow.AllowRule(rule)
+	}
+	for _, finding := range r.allowedSecrets {
+		r.allow.AllowSecret(secretHash(p, sanitizer.SecretValue(finding.Match)), finding.Type)
+	}
+	return r.allow.Save()
+}
+
+// ask prompts for one finding until a valid answer is given. io.EOF means
+// the user quit the review.
+func (r *review) ask(n, total int, finding scanner.Finding) (bool, error) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+
+	line := ""
+	if finding.Line > 0 && finding.Line <= len(r.content) {
+		line = r.content[finding.Line-1]
+	}
+
+	for {
+		fmt.Printf("Finding %d/%d: %s, line %d, %s\n", n, total, yellow(finding.Type), finding.Line, severityColor(finding.Severity))
+		fmt.Printf("  %s %s\n", red("-"), sanitizer.Preview(line, finding))
+		fmt.Printf("  %s %s\n", green("+"), r.sanitizedLine(line, finding))
+		fmt.Print("Redact? [y]es, [n]o, [r]ule: skip " + finding.Type + " in this review, [i]gnore " + finding.Type + " from now on, [a]llowlist secret, [s]trategy, [q]uit: ")
+
+		answer, err := r.readLine()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes", "":
+			fmt.Println()
+			return true, nil
+		case "n", "no":
+			fmt.Println()
+			return false, nil
+		case "r", "rule":
+			r.skipped = append(r.skipped, finding.Type)
+			fmt.Println()
+			return false, nil
+		case "i", "ignore":
+			r.skipped = append(r.skipped, finding.Type)
+			r.allowedRules = append(r.allowedRules, finding.Type)
+			fmt.Println()
+			return false, nil
+		case "a", "allowlist":
+			r.allowedSecrets = append(r.allowedSecrets, finding)
+			fmt.Println()
+			return false, nil
+		case "s", "strategy":
+			if err := r.chooseStrategy(finding); err != nil {
+				return false, err
+			}
+		case "q", "quit":
+			return false, io.EOF
+		default:
+			fmt.Println(yellow("Unknown answer " + answer))
+		}
+	}
+}
+
+// chooseStrategy changes the redaction strategy of a single finding
+func (r *review) chooseStrategy(finding scanner.Finding) error {
+	names := sanitizer.RedactorNames()
+	if useVault {
+		// Only these strategies identify the secret in the vault
+		names = []string{"fake", "placeholder"}
+	}
+	fmt.Printf("Strategy (%s): ", strings.Join(names, ", "))
+	name, err := r.readLine()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(names, strings.ToLower(name)) {
+		fmt.Println(color.YellowString("Unknown strategy " + name))
+		return nil
+	}
+	redactor, err := sanitizer.NewRedactor(name, r.placeholder, r.shell)
+	if err != nil {
+		return err
+	}
+	if r.opts.FindingRedactors == nil {
+		r.opts.FindingRedactors = make(map[sanitizer.FindingKey]sanitizer.Redactor)
+	}
+	r.opts.FindingRedactors[sanitizer.KeyOf(finding)] = redactor
+	return nil
+}
+
+// sanitizedLine returns the line as the finding alone would leave it
+func (r *review) sanitizedLine(line string, finding scanner.Finding) string {
+	opts := sanitizer.Options{Redactor: r.opts.RedactorFor(finding)}
+	finding.Line = 1
+	sanitized := sanitizer.SanitizeWithOptions(line, []scanner.Finding{finding}, opts)
+	if sanitized == "" && line != "" {
+		return color.YellowString("(entry deleted)")
+	}
+	return sanitized
+}
+
+// confirm asks a yes/no question, defaulting to no
+func (r *review) confirm(question string) bool {
+	fmt.Print(question + " [y/N]: ")
+	answer, err := r.readLine()
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
+// readLine reads one answer from stdin
+func (r *review) readLine() (string, error) {
+	answer, err := r.in.ReadString('\n')
+	if err != nil && (answer == "" || !errors.Is(err, io.EOF)) {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
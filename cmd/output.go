@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// interactive reports whether stdout is a terminal. Output piped to files
+// or CI logs gets neither color nor emoji.
+func interactive() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// plainOutput disables color when stdout is not a terminal, whatever the
+// terminal settings of stderr
+func plainOutput() {
+	if !interactive() {
+		color.NoColor = true
+	}
+}
+
+// icon returns emoji on a terminal and its plain text replacement
+// otherwise
+func icon(emoji, plain string) string {
+	if interactive() {
+		return emoji
+	}
+	return plain
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/safefile"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	purgeFiles  []string
+	purgeDryRun bool
+)
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Securely delete leftover unsanitized copies of history files",
+	Long: `Find files next to your history files that may still hold secrets after
+sanitizing: .backup and .sanitized outputs, zsh's .LOCK and .new files, and
+temporary files of interrupted rewrites. Each one is overwritten with zeros
+before being deleted.
+
+Overwriting is best effort: copy-on-write and journaling filesystems,
+snapshots and SSDs may keep the old contents. Backups made by --in-place
+are managed separately with "backup purge".`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runPurge,
+}
+
+func init() {
+	purgeCmd.Flags().StringArrayVarP(&purgeFiles, "file", "f", nil, "History file to clean up after (repeatable, default: all known history locations)")
+	purgeCmd.Flags().BoolVarP(&purgeDryRun, "dry-run", "d", false, "List the files that would be deleted")
+	rootCmd.AddCommand(purgeCmd)
+}
+
+func runPurge(cmd *cobra.Command, args []string) error {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	locations := purgeFiles
+	if len(locations) == 0 {
+		locations = history.KnownLocations()
+	}
+
+	var removed, failed int
+	var freed int64
+	for _, location := range locations {
+		for _, path := range history.Leftovers(location) {
+			// A fresh lock file belongs to a shell writing its history now
+			if strings.HasSuffix(path, ".LOCK") && !safefile.StaleLock(path) {
+				fmt.Printf("%s %s (in use by a shell, skipped)\n", yellow("⚠"), path)
+				continue
+			}
+
+			var size int64
+			if info, err := os.Lstat(path); err == nil {
+				size = info.Size()
+			}
+
+			if purgeDryRun {
+				fmt.Printf("  %s (%d bytes)\n", path, size)
+				removed++
+				freed += size
+				continue
+			}
+			if err := safefile.Shred(path); err != nil {
+				fmt.Printf("%s %s: %v\n", red("✗"), path, err)
+				failed++
+				continue
+			}
+			fmt.Printf("%s Removed %s (%d bytes)\n", green("✓"), path, size)
+			removed++
+			freed += size
+		}
+	}
+
+	switch {
+	case removed == 0 && failed == 0:
+		fmt.Println(green("✓ No leftover copies found"))
+	case purgeDryRun:
+		fmt.Printf("\n%s %d file(s), %d bytes would be removed\n", yellow("🔸"), removed, freed)
+	default:
+		fmt.Printf("\n%s Removed %d file(s), %d bytes\n", green("✓"), removed, freed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d file(s)", failed)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arnac-io/history-sanitizer/pkg/safefile"
+	"github.com/arnac-io/history-sanitizer/pkg/vault"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreFile    string
+	restoreOutput  string
+	restoreInPlace bool
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [placeholder...]",
+	Short: "Re-inject original secrets from the vault into a sanitized file",
+	Long: `Replace placeholders in a sanitized history file with the original secrets
+stored in the vault by a previous run with --vault. Without arguments,
+every placeholder found in the vault is restored.`,
+	SilenceUsage: true,
+	RunE:         runRestore,
+}
+
+func init() {
+	restoreCmd.Flags().StringVarP(&restoreFile, "file", "f", "", "Sanitized history file (required)")
+	restoreCmd.Flags().StringVarP(&restoreOutput, "output", "o", "", "Output file (default: <input>.restored)")
+	restoreCmd.Flags().BoolVarP(&restoreInPlace, "in-place", "i", false, "Rewrite the sanitized file itself")
+	restoreCmd.Flags().StringVar(&vaultFile, "vault-file", "", "Vault location (default: <config dir>/vault.json)")
+	restoreCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(restoreCmd)
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	content, err := os.ReadFile(restoreFile)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	v, err := openVault(true)
+	if err != nil {
+		return err
+	}
+
+	placeholders := args
+	if len(placeholders) == 0 {
+		placeholders = v.Placeholders()
+	}
+
+	restored := string(content)
+	count := 0
+	for _, placeholder := range placeholders {
+		n := strings.Count(restored, placeholder)
+		if n == 0 {
+			continue
+		}
+		secret, err := v.Get(placeholder)
+		if errors.Is(err, vault.ErrNotFound) {
+			fmt.Printf("%s %s not in vault, skipped\n", yellow("⚠"), placeholder)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		restored = strings.ReplaceAll(restored, placeholder, secret)
+		count += n
+	}
+
+	if count == 0 {
+		fmt.Println(yellow("No placeholders from the vault found in " + restoreFile))
+		return nil
+	}
+
+	target := restoreOutput
+	if restoreInPlace {
+		target = restoreFile
+	} else if target == "" {
+		target = restoreFile + ".restored"
+	}
+	if err := safefile.WriteFile(target, []byte(restored), 0600); err != nil {
+		return fmt.Errorf("failed to write restored file: %w", err)
+	}
+
+	fmt.Printf("%s Restored %d secret(s) into %s\n", green("✓"), count, green(target))
+	return nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/arnac-io/history-sanitizer/pkg/vault"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var revealCmd = &cobra.Command{
+	Use:   "reveal [placeholder...]",
+	Short: "Print the original secrets behind placeholders",
+	Long: `Decrypt and print the original secrets stored in the vault by a previous
+run with --vault. Without arguments, every placeholder in the vault is
+revealed.`,
+	SilenceUsage: true,
+	RunE:         runReveal,
+}
+
+func init() {
+	revealCmd.Flags().StringVar(&vaultFile, "vault-file", "", "Vault location (default: <config dir>/vault.json)")
+	rootCmd.AddCommand(revealCmd)
+}
+
+func runReveal(cmd *cobra.Command, args []string) error {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	v, err := openVault(true)
+	if err != nil {
+		return err
+	}
+
+	placeholders := args
+	if len(placeholders) == 0 {
+		placeholders = v.Placeholders()
+	}
+
+	for _, placeholder := range placeholders {
+		secret, err := v.Get(placeholder)
+		if errors.Is(err, vault.ErrNotFound) {
+			fmt.Printf("%s %s\n", red("✗"), placeholder+": not in vault")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s (%s)\n  %s\n", yellow(placeholder), v.Rule(placeholder), secret)
+	}
+
+	return nil
+}
@@ -1,13 +1,23 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/arnac-io/history-sanitizer/pkg/backup"
+	"github.com/arnac-io/history-sanitizer/pkg/checkpoint"
+	"github.com/arnac-io/history-sanitizer/pkg/config"
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/report"
+	"github.com/arnac-io/history-sanitizer/pkg/safefile"
 	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
 	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+	"github.com/arnac-io/history-sanitizer/pkg/vault"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -18,10 +28,32 @@ var (
 	dryRun      bool
 	verbose     bool
 	inPlace     bool
+	minSeverity string
+	redactLevel string
+	redactWith  string
+	redactRules []string
+	idLength    int
+	shellName   string
+	useVault    bool
+	// reportFormat is text for the colored console output, or one of
+	// report.Formats
+	reportFormat string
+	showSecrets  bool
+	auditFile    string
+	reviewMode   bool
+	showDiff     bool
+	encryptBak   bool
+	backupKeep   int
+	backupAge    string
+	baselineFile string
+	useBaseline  bool
 	// Version will be set by the main package at build time
 	Version = "dev"
 )
 
+// formatText is the --format of the human-readable console output
+const formatText = "text"
+
 var rootCmd = &cobra.Command{
 	Use:   "history-sanitizer",
 	Short: "Scan and sanitize sensitive information from shell history",
@@ -39,7 +71,25 @@ func init() {
 	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: <input>.sanitized)")
 	rootCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show what would be changed without modifying files")
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed information")
-	rootCmd.Flags().BoolVarP(&inPlace, "in-place", "i", false, "Replace the original file (creates backup with .backup suffix)")
+	rootCmd.Flags().BoolVarP(&inPlace, "in-place", "i", false, "Replace the original file (a timestamped backup is stored first)")
+	rootCmd.Flags().StringVar(&minSeverity, "min-severity", "low", "Only report findings at or above this severity (critical, high, medium, low)")
+	rootCmd.Flags().StringVar(&redactLevel, "redact-severity", "low", "Only redact findings at or above this severity, warn about the rest")
+	rootCmd.Flags().StringVar(&redactWith, "redact", "placeholder", "Redaction strategy: "+strings.Join(sanitizer.RedactorNames(), ", "))
+	rootCmd.Flags().StringArrayVar(&redactRules, "redact-rule", nil, "Redaction strategy for a rule, as rule=strategy (repeatable)")
+	rootCmd.Flags().StringVar(&shellName, "shell", "auto", "Shell that wrote the history, for --redact env (auto, bash, zsh, fish, powershell)")
+	rootCmd.Flags().StringVar(&reportFormat, "format", formatText, "Report format: "+formatText+", "+strings.Join(report.Formats, ", ")+" (reports go to stdout, messages to stderr)")
+	rootCmd.Flags().StringVar(&auditFile, "report", "", "Write a human-readable audit report to this file (.html or .md)")
+	rootCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Include raw secrets in machine-readable reports and --diff")
+	rootCmd.Flags().BoolVar(&showDiff, "diff", false, "Print a unified diff of the changes (secrets masked unless --show-secrets)")
+	rootCmd.Flags().BoolVar(&incremental, "incremental", false, "Only scan the entries appended since the last --incremental run (requires --in-place)")
+	rootCmd.Flags().BoolVar(&reviewMode, "interactive", false, "Review each finding and choose whether and how to redact it")
+	rootCmd.Flags().BoolVar(&useVault, "vault", false, "Keep the original secrets encrypted in a vault so they can be revealed or restored later")
+	rootCmd.Flags().StringVar(&vaultFile, "vault-file", "", "Vault location (default: <config dir>/vault.json)")
+	rootCmd.Flags().BoolVar(&encryptBak, "encrypt-backup", false, "Encrypt the backup taken by --in-place with the vault passphrase")
+	rootCmd.Flags().IntVar(&backupKeep, "backup-keep", 10, "Number of backups kept per history file (0 keeps all)")
+	rootCmd.Flags().StringVar(&backupAge, "backup-max-age", "", "Delete backups older than this, e.g. 30d (default: no limit)")
+	addBaselineFlag(rootCmd)
+	rootCmd.Flags().IntVar(&idLength, "placeholder-length", sanitizer.DefaultPlaceholderLength, "Number of hex characters of the keyed hash kept in placeholders")
 }
 
 func Execute() error {
@@ -52,42 +102,279 @@ func runSanitizer(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("history file not found: %s", historyFile)
 	}
 
+	reportSeverity, err := scanner.ParseSeverity(minSeverity)
+	if err != nil {
+		return fmt.Errorf("invalid --min-severity: %w", err)
+	}
+	redactSeverity, err := scanner.ParseSeverity(redactLevel)
+	if err != nil {
+		return fmt.Errorf("invalid --redact-severity: %w", err)
+	}
+	if err := checkReportFlags(); err != nil {
+		return err
+	}
+	if reviewMode && reportFormat != formatText {
+		return fmt.Errorf("--interactive cannot be combined with --format %s", reportFormat)
+	}
+	// Earlier entries are only left out safely when they were sanitized in
+	// place; an output file would get them unsanitized
+	if incremental && !inPlace && !dryRun {
+		return fmt.Errorf("--incremental requires --in-place (or --dry-run)")
+	}
 	// Set default output file
 	if outputFile == "" {
 		outputFile = historyFile + ".sanitized"
 	}
 
+	// Machine-readable reports own stdout, progress messages go to stderr
+	out := io.Writer(os.Stdout)
+	if reportFormat != formatText {
+		out = os.Stderr
+	}
+
 	yellow := color.New(color.FgYellow).SprintFunc()
 	green := color.New(color.FgGreen).SprintFunc()
 	red := color.New(color.FgRed).SprintFunc()
 
-	fmt.Printf("🔍 Scanning history file: %s\n", yellow(historyFile))
+	fmt.Fprintf(out, "🔍 Scanning history file: %s\n", yellow(historyFile))
 
-	// Read history file
-	content, err := os.ReadFile(historyFile)
+	purpose := ""
+	if incremental {
+		purpose = checkpointSanitize
+	}
+	// --diff shows the placeholders a real run would write, so it needs the
+	// stored key even under --dry-run
+	scan, err := scanHistory(historyFile, reportSeverity, purpose, dryRun && !showDiff)
 	if err != nil {
-		return fmt.Errorf("failed to read history file: %w", err)
+		return err
+	}
+	content, findings := scan.content, scan.findings
+	if incremental {
+		printCheckpoint(out, scan)
 	}
+	printSuppressed(out, scan.report.Summary.Suppressed)
 
-	// Scan for sensitive data
-	findings, err := scanner.ScanContent(string(content))
+	opts, err := redactionOptions(scan.placeholder, scan.shell)
 	if err != nil {
-		return fmt.Errorf("failed to scan content: %w", err)
+		return err
+	}
+	opts.MinSeverity = redactSeverity
+
+	if err := emitReports(out, []report.Report{scan.report}); err != nil {
+		return err
 	}
 
 	if len(findings) == 0 {
-		fmt.Println(green("✓ No sensitive information found!"))
+		fmt.Fprintln(out, green("✓ No sensitive information found!"))
+		if incremental && !dryRun {
+			return recordCheckpoint(checkpointSanitize, historyFile, content)
+		}
 		return nil
 	}
 
-	fmt.Printf("\n%s Found %d sensitive pattern(s)\n\n", red("⚠"), len(findings))
+	fmt.Fprintf(out, "\n%s Found %d sensitive pattern(s)\n\n", red("⚠"), len(findings))
+
+	// Display findings with obfuscation, or let the user pick the ones to
+	// redact
+	if reviewMode {
+		if findings, err = reviewFindings(string(content), findings, &opts, scan.placeholder, scan.shell, scan.allow); err != nil {
+			return err
+		}
+		if len(findings) == 0 {
+			fmt.Fprintln(out, green("✓ No finding selected for redaction"))
+			return nil
+		}
+		fmt.Fprintf(out, "%d finding(s) selected for redaction\n\n", len(findings))
+	} else if reportFormat == formatText {
+		printFindings(string(content), findings, opts)
+	}
+
+	if showDiff {
+		target := outputFile
+		if inPlace {
+			target = historyFile
+		}
+		sanitized := sanitizer.SanitizeWithOptions(string(content), findings, opts)
+		printDiff(out, historyFile, target, string(content), sanitized, scan.findings)
+	}
+
+	if dryRun {
+		fmt.Fprintln(out, yellow("🔸 Dry run mode - no files will be modified"))
+		return nil
+	}
+
+	return writeSanitized(out, content, findings, opts, reportSeverity)
+}
+
+// writeSanitized redacts the findings and writes the result to the
+// output file, or replaces the history file with --in-place. Commands
+// appended during the scan with findings at or above reportSeverity are
+// redacted too.
+func writeSanitized(out io.Writer, content []byte, findings []scanner.Finding, opts sanitizer.Options, reportSeverity scanner.Severity) error {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+
+	// Keep the originals in the vault, keyed by their replacement
+	var secrets *vault.Vault
+	var err, vaultErr error
+	if useVault {
+		if err := checkVaultStrategies(); err != nil {
+			return err
+		}
+		if secrets, err = openVault(false); err != nil {
+			return err
+		}
+		opts.Record = func(replacement, secret string, finding scanner.Finding) {
+			if err := secrets.Put(replacement, secret, finding.Type); err != nil && vaultErr == nil {
+				vaultErr = err
+			}
+		}
+	}
+
+	// Ask for the backup passphrase before taking the lock, so the shells
+	// are not blocked while the user types
+	var backupPass []byte
+	if inPlace && encryptBak {
+		if backupPass, err = readPassphrase(true); err != nil {
+			return err
+		}
+	}
+	retention := backup.Retention{Keep: backupKeep}
+	if retention.MaxAge, err = backup.ParseAge(backupAge); err != nil {
+		return fmt.Errorf("invalid --backup-max-age: %w", err)
+	}
+
+	// Hold the shells' history lock while the file is rewritten, so no
+	// command is appended between the final read and the replacement
+	var locked *os.File
+	if inPlace {
+		f, unlock, err := safefile.Lock(historyFile)
+		if err != nil {
+			return fmt.Errorf("failed to lock history file: %w", err)
+		}
+		defer unlock()
+		locked = f
+	}
+
+	// Sanitize content
+	sanitized := sanitizer.SanitizeWithOptions(string(content), findings, opts)
+
+	if inPlace {
+		// Merge commands the shells appended while we were scanning. Read
+		// through the locked file: opening it again would drop the lock
+		current, err := readLocked(locked)
+		if err != nil {
+			return fmt.Errorf("failed to read history file: %w", err)
+		}
+		tail, ok := safefile.Appended(content, current)
+		if !ok {
+			return fmt.Errorf("history file was rewritten by another process during the scan, run again to sanitize it")
+		}
+		if len(tail) > 0 {
+			tailFindings, err := scanner.ScanContent(string(tail))
+			if err != nil {
+				return fmt.Errorf("failed to scan content: %w", err)
+			}
+			var kept []scanner.Finding
+			for _, finding := range tailFindings {
+				if finding.Severity >= reportSeverity {
+					kept = append(kept, finding)
+				}
+			}
+			kept, _ = scanner.FilterAllowed(string(tail), kept)
+			sanitized += sanitizer.SanitizeWithOptions(string(tail), kept, opts)
+			fmt.Fprintf(out, "%s Merged %d line(s) appended during the scan (%d finding(s))\n", yellow("⚠"), strings.Count(string(tail), "\n"), len(kept))
+		}
+		content = current
+	}
+
+	if secrets != nil {
+		if vaultErr != nil {
+			return fmt.Errorf("failed to store secrets in vault: %w", vaultErr)
+		}
+		// Save the vault before touching the history so no secret is lost
+		if err := secrets.Save(); err != nil {
+			return err
+		}
+		vaultPath, _ := resolveVaultPath()
+		fmt.Fprintf(out, "%s Original secrets stored in vault: %s\n", green("✓"), vaultPath)
+	}
+
+	if inPlace {
+		// Back up the unsanitized history outside the history directory
+		store, err := backup.DefaultStore()
+		if err != nil {
+			return err
+		}
+		saved, err := store.Create(historyFile, content, backupPass)
+		if err != nil {
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
+		fmt.Fprintf(out, "%s Backup created: %s (restore with: %s backup restore %s)\n", green("✓"), saved.ID, os.Args[0], saved.ID)
+
+		// Atomically replace the original file, keeping its permissions
+		err = safefile.WriteFile(historyFile, []byte(sanitized), 0600)
+		if err != nil {
+			return fmt.Errorf("failed to write sanitized file: %w", err)
+		}
+		fmt.Fprintf(out, "%s History file sanitized: %s\n", green("✓"), green(historyFile))
+
+		if incremental {
+			if err := recordCheckpoint(checkpointSanitize, historyFile, []byte(sanitized)); err != nil {
+				return fmt.Errorf("failed to record checkpoint: %w", err)
+			}
+		}
+
+		expired, err := store.Prune(retention)
+		if err != nil {
+			return fmt.Errorf("failed to apply backup retention: %w", err)
+		}
+		if len(expired) > 0 {
+			fmt.Fprintf(out, "%s Deleted %d expired backup(s)\n", green("✓"), len(expired))
+		}
+	} else {
+		// Write to output file
+		err = safefile.WriteFile(outputFile, []byte(sanitized), 0600)
+		if err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+
+		fmt.Fprintf(out, "%s Sanitized history saved to: %s\n", green("✓"), green(outputFile))
+		fmt.Fprintf(out, "\nOriginal file preserved at: %s\n", historyFile)
+		fmt.Fprintln(out, "\nTo automatically replace your history file, use:")
+		fmt.Fprintf(out, "  %s -f %s -i\n", os.Args[0], historyFile)
+	}
+
+	return nil
+}
+
+// readLocked reads the whole history file held by safefile.Lock through
+// its locked descriptor
+func readLocked(f *os.File) ([]byte, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
+// printFindings displays the findings with their secrets masked
+func printFindings(content string, findings []scanner.Finding, opts sanitizer.Options) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
 
-	// Display findings with obfuscation
 	lines := strings.Split(string(content), "\n")
 	for i, finding := range findings {
 		fmt.Printf("Finding #%d:\n", i+1)
 		fmt.Printf("  Type: %s\n", yellow(finding.Type))
 		fmt.Printf("  Line: %d\n", finding.Line)
+		if len(finding.RuleIDs) > 1 {
+			fmt.Printf("  Rules: %s\n", strings.Join(finding.RuleIDs, ", "))
+		}
+		fmt.Printf("  Severity: %s (confidence %.0f%%)\n", severityColor(finding.Severity), finding.Confidence*100)
+		if finding.Validated {
+			fmt.Printf("  Validated: %s\n", green("✓ checksum/structure verified"))
+		}
 
 		// Show the full line with obfuscated secret
 		if finding.Line > 0 && finding.Line <= len(lines) {
@@ -97,47 +384,261 @@ func runSanitizer(cmd *cobra.Command, args []string) error {
 			fmt.Printf("  Command: %s\n", sanitizedLine)
 		}
 
+		if !opts.ShouldRedact(finding) {
+			fmt.Printf("  Action: %s\n", yellow("warn only (below --redact-severity)"))
+		}
+
 		if verbose {
 			fmt.Printf("  Secret: %s\n", red(finding.Match))
 		}
 		fmt.Println()
 	}
+}
 
-	if dryRun {
-		fmt.Println(yellow("🔸 Dry run mode - no files will be modified"))
-		return nil
+// checkReportFlags validates --format and --report before any work is done
+func checkReportFlags() error {
+	if reportFormat != formatText && !slices.Contains(report.Formats, reportFormat) {
+		return fmt.Errorf("invalid --format %q (expected %s or %s)", reportFormat, formatText, strings.Join(report.Formats, ", "))
 	}
+	if auditFile != "" {
+		if _, err := report.AuditFormat(auditFile); err != nil {
+			return fmt.Errorf("invalid --report: %w", err)
+		}
+	}
+	return nil
+}
 
-	// Sanitize content
-	sanitized := sanitizer.Sanitize(string(content), findings)
+// scanResult is a scanned history file
+type scanResult struct {
+	content     []byte
+	shell       history.Shell
+	placeholder sanitizer.Placeholder
+	// findings at or above the reporting severity
+	findings []scanner.Finding
+	report   report.Report
+	// allow is the allowlist the findings were filtered with
+	allow *config.Allowlist
+	// status and entries tell how much of the history was scanned
+	status  checkpoint.Status
+	entries int
+}
 
-	if inPlace {
-		// Create backup with timestamp
-		backupFile := historyFile + ".backup"
-		err = os.WriteFile(backupFile, content, 0600)
-		if err != nil {
-			return fmt.Errorf("failed to create backup file: %w", err)
+// scanHistory reads and scans a history file, keeping the findings at or
+// above minSeverity. With a checkpoint purpose, only the entries appended
+// since the checkpoint are scanned. A readOnly scan never creates the
+// placeholder key, see placeholderKey.
+func scanHistory(path string, minSeverity scanner.Severity, purpose string, readOnly bool) (*scanResult, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	shell, err := history.ParseShell(shellName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --shell: %w", err)
+	}
+	if shell == history.ShellUnknown {
+		shell = history.DetectShell(path, string(content))
+	}
+
+	key, err := placeholderKey(readOnly)
+	if err != nil {
+		return nil, err
+	}
+	placeholder := sanitizer.Placeholder{Key: key, Length: idLength}
+
+	format := history.DetectFormat(shell, string(content))
+
+	// With a checkpoint, only scan what was appended since
+	from, status := 0, checkpoint.StatusNew
+	if purpose != "" {
+		if from, status, err = resumeScan(purpose, path, content); err != nil {
+			return nil, err
 		}
-		fmt.Printf("%s Backup created: %s\n", green("✓"), backupFile)
+	}
 
-		// Replace original file
-		err = os.WriteFile(historyFile, []byte(sanitized), 0600)
-		if err != nil {
-			return fmt.Errorf("failed to write sanitized file: %w", err)
+	// Scan for sensitive data
+	allFindings, err := scanner.ScanContent(string(content[from:]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan content: %w", err)
+	}
+	if from > 0 {
+		shift := bytes.Count(content[:from], []byte("\n"))
+		for i := range allFindings {
+			allFindings[i].Line += shift
 		}
-		fmt.Printf("%s History file sanitized: %s\n", green("✓"), green(historyFile))
-	} else {
-		// Write to output file
-		err = os.WriteFile(outputFile, []byte(sanitized), 0600)
+	}
+
+	var findings []scanner.Finding
+	for _, finding := range allFindings {
+		if finding.Severity >= minSeverity {
+			findings = append(findings, finding)
+		}
+	}
+	findings, allowed := scanner.FilterAllowed(string(content), findings)
+
+	// Drop the rules and secrets the user allowlisted
+	allow, err := config.LoadAllowlist()
+	if err != nil {
+		return nil, err
+	}
+	var notAllowlisted []scanner.Finding
+	for _, finding := range findings {
+		if !allowlisted(allow, placeholder, finding) {
+			notAllowlisted = append(notAllowlisted, finding)
+		}
+	}
+	allowlistedCount := len(findings) - len(notAllowlisted)
+	findings = notAllowlisted
+
+	reportOpts := report.Options{
+		Placeholder: placeholder,
+		ShowSecrets: showSecrets,
+		Version:     Version,
+	}
+	r := report.New(path, string(content), format, findings, reportOpts)
+
+	// Drop the findings acknowledged in the baseline, matched by fingerprint
+	suppressed := 0
+	if baselineGiven() {
+		base, err := loadBaseline(key)
 		if err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
+			return nil, err
+		}
+		var kept []scanner.Finding
+		for i, f := range r.Findings {
+			if !base.Contains(f.Fingerprint) {
+				kept = append(kept, findings[i])
+			}
+		}
+		if suppressed = len(findings) - len(kept); suppressed > 0 {
+			findings = kept
+			r = report.New(path, string(content), format, findings, reportOpts)
 		}
+	}
+	r.Summary.Suppressed = report.Suppressed{Inline: allowed, Allowlist: allowlistedCount, Baseline: suppressed}
+
+	return &scanResult{
+		content:     content,
+		shell:       shell,
+		placeholder: placeholder,
+		findings:    findings,
+		report:      r,
+		allow:       allow,
+		status:      status,
+		entries:     len(history.ParseEntries(string(content[from:]), format)),
+	}, nil
+}
+
+// placeholderKey returns the key placeholders and fingerprints are derived
+// from. Runs that write nothing, such as scan and --dry-run, use the stored
+// key if there is one and an ephemeral key otherwise, so that they work
+// without creating the config directory.
+func placeholderKey(readOnly bool) ([]byte, error) {
+	if !readOnly {
+		return config.LoadKey()
+	}
+	key, err := config.ReadKey()
+	if err != nil || key != nil {
+		return key, err
+	}
+	return config.EphemeralKey()
+}
 
-		fmt.Printf("%s Sanitized history saved to: %s\n", green("✓"), green(outputFile))
-		fmt.Printf("\nOriginal file preserved at: %s\n", historyFile)
-		fmt.Println("\nTo automatically replace your history file, use:")
-		fmt.Printf("  %s -f %s -i\n", os.Args[0], historyFile)
+// printSuppressed tells how many findings were acknowledged and left out
+func printSuppressed(out io.Writer, s report.Suppressed) {
+	if s.Inline > 0 {
+		fmt.Fprintf(out, "%d finding(s) suppressed by inline hs:allow markers\n", s.Inline)
+	}
+	if s.Allowlist > 0 {
+		fmt.Fprintf(out, "%d finding(s) suppressed by the allowlist\n", s.Allowlist)
 	}
+	if s.Baseline > 0 {
+		fmt.Fprintf(out, "%d acknowledged finding(s) suppressed by the baseline\n", s.Baseline)
+	}
+}
+
+// emitReports writes the --format report to stdout and the --report audit
+// report, telling out where the latter went
+func emitReports(out io.Writer, reports []report.Report) error {
+	if reportFormat != formatText {
+		for _, r := range reports {
+			if err := report.Write(os.Stdout, reportFormat, r); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+		}
+	}
+	if auditFile != "" {
+		if err := writeAudit(auditFile, reports); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Audit report written to: %s\n", auditFile)
+	}
+	return nil
+}
 
+// writeAudit writes the audit report of the scans to path, in the format
+// given by its extension
+func writeAudit(path string, reports []report.Report) error {
+	format, err := report.AuditFormat(path)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := report.WriteAudit(&buf, format, reports); err != nil {
+		return fmt.Errorf("failed to render audit report: %w", err)
+	}
+	if err := safefile.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write audit report: %w", err)
+	}
 	return nil
 }
+
+// severityColor renders a severity name colored by its level
+func severityColor(s scanner.Severity) string {
+	switch s {
+	case scanner.SeverityCritical:
+		return color.New(color.FgRed, color.Bold).Sprint(s)
+	case scanner.SeverityHigh:
+		return color.New(color.FgRed).Sprint(s)
+	case scanner.SeverityMedium:
+		return color.New(color.FgYellow).Sprint(s)
+	default:
+		return color.New(color.FgCyan).Sprint(s)
+	}
+}
+
+// redactionOptions builds the sanitizer strategies from --redact and
+// --redact-rule, deriving placeholders and fake values with placeholder
+func redactionOptions(placeholder sanitizer.Placeholder, shell history.Shell) (sanitizer.Options, error) {
+	return newRedactionOptions(redactWith, redactRules, placeholder, shell)
+}
+
+// newRedactionOptions builds the sanitizer strategies from a strategy name
+// and rule=strategy pairs, as given to --redact and --redact-rule
+func newRedactionOptions(strategy string, rules []string, placeholder sanitizer.Placeholder, shell history.Shell) (sanitizer.Options, error) {
+	var opts sanitizer.Options
+
+	redactor, err := sanitizer.NewRedactor(strategy, placeholder, shell)
+	if err != nil {
+		return opts, fmt.Errorf("invalid --redact: %w", err)
+	}
+	opts.Redactor = redactor
+
+	for _, rule := range rules {
+		id, name, ok := strings.Cut(rule, "=")
+		if !ok || id == "" {
+			return opts, fmt.Errorf("invalid --redact-rule %q, expected rule=strategy", rule)
+		}
+		r, err := sanitizer.NewRedactor(name, placeholder, shell)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --redact-rule %q: %w", rule, err)
+		}
+		if opts.RuleRedactors == nil {
+			opts.RuleRedactors = make(map[string]sanitizer.Redactor)
+		}
+		opts.RuleRedactors[id] = r
+	}
+
+	return opts, nil
+}
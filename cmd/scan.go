@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arnac-io/history-sanitizer/pkg/report"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var failOn string
+
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Scan a history file without modifying anything, for scripts and CI",
+	Long: `Scan a history file and report the secrets found, without writing any file
+other than --report and the --incremental checkpoint. The placeholder key is
+only read: without one, a key for this run only is used.
+
+Exit codes:
+  0  no finding at or above --fail-on
+  1  findings at or above --fail-on
+  2  error
+
+Color and emoji are disabled when stdout is not a terminal.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runScan,
+}
+
+func init() {
+	homeDir, _ := os.UserHomeDir()
+
+	scanCmd.Flags().StringVarP(&historyFile, "file", "f", filepath.Join(homeDir, ".zsh_history"), "Path to history file")
+	scanCmd.Flags().StringVar(&minSeverity, "min-severity", "low", "Only report findings at or above this severity (critical, high, medium, low)")
+	scanCmd.Flags().StringVar(&failOn, "fail-on", "low", "Exit with code 1 when a finding is at or above this severity (critical, high, medium, low, none)")
+	scanCmd.Flags().StringVar(&reportFormat, "format", formatText, "Report format: "+formatText+", "+strings.Join(report.Formats, ", "))
+	scanCmd.Flags().StringVar(&auditFile, "report", "", "Write a human-readable audit report to this file (.html or .md)")
+	scanCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Include raw secrets in machine-readable reports")
+	scanCmd.Flags().StringVar(&shellName, "shell", "auto", "Shell that wrote the history (auto, bash, zsh, fish, powershell)")
+	scanCmd.Flags().BoolVar(&incremental, "incremental", false, "Only scan the entries appended since the last passing --incremental scan")
+	addBaselineFlag(scanCmd)
+	rootCmd.AddCommand(scanCmd)
+}
+
+func runScan(cmd *cobra.Command, args []string) error {
+	plainOutput()
+
+	reportSeverity, err := scanner.ParseSeverity(minSeverity)
+	if err != nil {
+		return fmt.Errorf("invalid --min-severity: %w", err)
+	}
+	// A threshold above critical never fails
+	threshold := scanner.SeverityCritical + 1
+	if !strings.EqualFold(failOn, "none") {
+		if threshold, err = scanner.ParseSeverity(failOn); err != nil {
+			return fmt.Errorf("invalid --fail-on: %w", err)
+		}
+	}
+	if err := checkReportFlags(); err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if reportFormat != formatText {
+		out = os.Stderr
+	}
+
+	purpose := ""
+	if incremental {
+		purpose = checkpointScan
+	}
+	scan, err := scanHistory(historyFile, reportSeverity, purpose, true)
+	if err != nil {
+		return err
+	}
+	if incremental {
+		printCheckpoint(out, scan)
+	}
+	if err := emitReports(out, []report.Report{scan.report}); err != nil {
+		return err
+	}
+
+	failing := 0
+	for _, f := range scan.findings {
+		if f.Severity >= threshold {
+			failing++
+		}
+	}
+
+	if reportFormat == formatText {
+		printScanFindings(out, scan.report)
+	}
+	printSuppressed(out, scan.report.Summary.Suppressed)
+
+	if failing > 0 {
+		// The checkpoint stays put, so the failing findings are reported
+		// again until they are sanitized
+		fmt.Fprintf(out, "%s %d finding(s), %d at or above --fail-on %s\n", color.RedString(icon("✗", "FAIL:")), len(scan.findings), failing, failOn)
+		return &FindingsError{Count: failing, Threshold: threshold}
+	}
+
+	// The run passed, later runs start after what it covered
+	if incremental {
+		if err := recordCheckpoint(checkpointScan, historyFile, scan.content); err != nil {
+			return fmt.Errorf("failed to record checkpoint: %w", err)
+		}
+	}
+	if len(scan.findings) == 0 {
+		fmt.Fprintf(out, "%s No sensitive information found in %s\n", color.GreenString(icon("✓", "OK:")), historyFile)
+	} else {
+		fmt.Fprintf(out, "%s %d finding(s), none at or above --fail-on %s\n", color.YellowString(icon("⚠", "WARN:")), len(scan.findings), failOn)
+	}
+	return nil
+}
+
+// printScanFindings lists findings one per line, as file:line:column
+// followed by the severity, rule and masked command
+func printScanFindings(out io.Writer, r report.Report) {
+	for _, f := range r.Findings {
+		sev, _ := scanner.ParseSeverity(f.Severity)
+		fmt.Fprintf(out, "%s:%d:%d  %s  %s  %s\n", r.File, f.Line, f.Column, severityColor(sev), f.RuleID, f.Preview)
+	}
+	if len(r.Findings) > 0 {
+		fmt.Fprintln(out)
+	}
+}
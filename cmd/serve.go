@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/arnac-io/history-sanitizer/pkg/daemon"
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Answer checks from a local daemon, so shell hooks skip the startup cost",
+	Long: `Listen on a Unix domain socket, readable by the user only, and answer the
+checks of the shell hooks with the rules compiled and the configuration
+loaded once. "check" uses the daemon when it is running and scans in
+process otherwise.
+
+Each request and response is a JSON object on one line:
+
+  {"op":"scan","command":"..."}          findings of a command
+  {"op":"sanitize","command":"...",
+   "redact":"env","shell":"zsh"}          the redacted command and its findings
+  {"op":"reload-config"}                 reload the key and allowlist
+
+Requests also take "min_severity" and "redact_rules", like the check flags.
+Responses hold "ok", "error", "findings" (with masked previews) and
+"redacted". SIGHUP reloads the configuration too.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&socketPath, "socket", "", "Socket to listen on (default: <config dir>/daemon.sock)")
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	path := socketPath
+	if path == "" {
+		var err error
+		if path, err = daemon.DefaultSocket(); err != nil {
+			return err
+		}
+	}
+
+	c, err := newChecker()
+	if err != nil {
+		return err
+	}
+	scanner.CompileRules()
+
+	l, err := daemon.Listen(path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	// Closing the listener removes the socket
+	defer l.Close()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			if sig != syscall.SIGHUP {
+				l.Close()
+				return
+			}
+			if err := c.reload(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to reload the configuration: %v\n", err)
+			} else {
+				fmt.Println("Configuration reloaded")
+			}
+		}
+	}()
+
+	fmt.Printf("Listening on %s with %d rules, press Ctrl+C to stop\n", path, scanner.GetPatternCount())
+	if err := daemon.Serve(l, c.handle); err != nil {
+		return err
+	}
+	fmt.Println("Stopped serving")
+	return nil
+}
+
+// handle answers a request of the daemon protocol
+func (c *checker) handle(req daemon.Request) daemon.Response {
+	switch req.Op {
+	case daemon.OpScan, daemon.OpSanitize:
+	case daemon.OpReloadConfig:
+		if err := c.reload(); err != nil {
+			return daemon.Response{Error: err.Error()}
+		}
+		return daemon.Response{OK: true}
+	default:
+		return daemon.Response{Error: fmt.Sprintf("unknown op %q (expected %s, %s or %s)", req.Op, daemon.OpScan, daemon.OpSanitize, daemon.OpReloadConfig)}
+	}
+
+	severity := scanner.SeverityLow
+	if req.MinSeverity != "" {
+		var err error
+		if severity, err = scanner.ParseSeverity(req.MinSeverity); err != nil {
+			return daemon.Response{Error: fmt.Sprintf("invalid min_severity: %v", err)}
+		}
+	}
+	findings, err := c.scan(req.Command, severity)
+	if err != nil {
+		return daemon.Response{Error: err.Error()}
+	}
+
+	resp := daemon.Response{OK: true}
+	for _, f := range findings {
+		resp.Findings = append(resp.Findings, daemon.Finding{
+			Rule:     f.Type,
+			Severity: f.Severity.String(),
+			Line:     f.Line,
+			Start:    f.Start,
+			End:      f.End,
+			Preview:  sanitizer.ObfuscatePreview(f.Match, f.Type),
+		})
+	}
+
+	if req.Op == daemon.OpSanitize {
+		shell, err := history.ParseShell(req.Shell)
+		if err != nil {
+			return daemon.Response{Error: fmt.Sprintf("invalid shell: %v", err)}
+		}
+		strategy := req.Redact
+		if strategy == "" {
+			strategy = "placeholder"
+		}
+		if resp.Redacted, err = c.redact(req.Command, findings, strategy, req.RedactRules, shell); err != nil {
+			return daemon.Response{Error: err.Error()}
+		}
+	}
+	return resp
+}
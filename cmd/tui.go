@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+	"github.com/arnac-io/history-sanitizer/pkg/tui"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse findings in a full-screen terminal interface and choose the ones to redact",
+	Long: `Browse the findings of a history file grouped by rule, with the surrounding
+history of each. Findings can be filtered by rule, text or time range,
+selected one by one or in bulk, and the diff of the selected redactions is
+previewed before anything is written.
+
+Findings at or above --redact-severity are selected initially. The result is
+written like the main command does: to --output, or to the history file
+itself with --in-place after taking a backup.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runTUI,
+}
+
+func init() {
+	homeDir, _ := os.UserHomeDir()
+
+	tuiCmd.Flags().StringVarP(&historyFile, "file", "f", filepath.Join(homeDir, ".zsh_history"), "Path to history file")
+	tuiCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: <input>.sanitized)")
+	tuiCmd.Flags().BoolVarP(&inPlace, "in-place", "i", false, "Replace the original file (a timestamped backup is stored first)")
+	tuiCmd.Flags().StringVar(&minSeverity, "min-severity", "low", "Only list findings at or above this severity (critical, high, medium, low)")
+	tuiCmd.Flags().StringVar(&redactLevel, "redact-severity", "low", "Initially select findings at or above this severity")
+	tuiCmd.Flags().StringVar(&redactWith, "redact", "placeholder", "Redaction strategy: "+strings.Join(sanitizer.RedactorNames(), ", "))
+	tuiCmd.Flags().StringArrayVar(&redactRules, "redact-rule", nil, "Redaction strategy for a rule, as rule=strategy (repeatable)")
+	tuiCmd.Flags().StringVar(&shellName, "shell", "auto", "Shell that wrote the history, for --redact env (auto, bash, zsh, fish, powershell)")
+	addBaselineFlag(tuiCmd)
+	rootCmd.AddCommand(tuiCmd)
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	reportSeverity, err := scanner.ParseSeverity(minSeverity)
+	if err != nil {
+		return fmt.Errorf("invalid --min-severity: %w", err)
+	}
+	redactSeverity, err := scanner.ParseSeverity(redactLevel)
+	if err != nil {
+		return fmt.Errorf("invalid --redact-severity: %w", err)
+	}
+	if outputFile == "" {
+		outputFile = historyFile + ".sanitized"
+	}
+
+	scan, err := scanHistory(historyFile, reportSeverity, "", false)
+	if err != nil {
+		return err
+	}
+	if len(scan.findings) == 0 {
+		fmt.Println(color.GreenString("✓ No sensitive information found!"))
+		return nil
+	}
+
+	opts, err := redactionOptions(scan.placeholder, scan.shell)
+	if err != nil {
+		return err
+	}
+	opts.MinSeverity = redactSeverity
+
+	entries := history.ParseEntries(string(scan.content), scan.report.Format)
+	items := make([]tui.Item, len(scan.findings))
+	for i, f := range scan.findings {
+		items[i] = tui.Item{Finding: f, Selected: opts.ShouldRedact(f)}
+		if entry, ok := history.EntryAt(entries, f.Line); ok {
+			items[i].Timestamp = entry.Timestamp
+		}
+	}
+
+	// What the user selects is redacted whatever its severity
+	opts.MinSeverity = scanner.SeverityUnknown
+	model := tui.New(historyFile, string(scan.content), items, func(line string, findings []scanner.Finding) string {
+		return sanitizer.SanitizeWithOptions(line, findings, opts)
+	})
+	if err := tui.Run(model); err != nil {
+		return err
+	}
+
+	if !model.Write() {
+		fmt.Println(color.YellowString("No changes written"))
+		return nil
+	}
+	return writeSanitized(os.Stdout, scan.content, model.Selected(), opts, reportSeverity)
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arnac-io/history-sanitizer/pkg/config"
+	"github.com/arnac-io/history-sanitizer/pkg/vault"
+	"golang.org/x/term"
+)
+
+// vaultPassphraseEnv provides the vault passphrase non-interactively
+const vaultPassphraseEnv = "HISTORY_SANITIZER_VAULT_PASSPHRASE"
+
+// vaultFile is the --vault-file flag shared by the commands using the vault
+var vaultFile string
+
+// resolveVaultPath returns --vault-file or the default vault location
+func resolveVaultPath() (string, error) {
+	if vaultFile != "" {
+		return vaultFile, nil
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "vault.json"), nil
+}
+
+// openVault opens the vault, asking for the passphrase unless it is set in
+// the environment. With mustExist, a missing vault is an error instead of
+// being created.
+func openVault(mustExist bool) (*vault.Vault, error) {
+	path, err := resolveVaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	_, statErr := os.Stat(path)
+	exists := statErr == nil
+	if mustExist && !exists {
+		return nil, fmt.Errorf("vault not found: %s", path)
+	}
+
+	passphrase, err := readPassphrase(!exists)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := vault.Open(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// enteredPassphrase remembers the passphrase once entered, so the vault
+// and encrypted backups don't ask for it twice in the same run
+var enteredPassphrase []byte
+
+// readPassphrase reads the vault passphrase from the environment or the
+// terminal, asking twice when a new vault is being created. The same
+// passphrase encrypts backups.
+func readPassphrase(confirm bool) ([]byte, error) {
+	if enteredPassphrase != nil {
+		return enteredPassphrase, nil
+	}
+	if env := os.Getenv(vaultPassphraseEnv); env != "" {
+		return []byte(env), nil
+	}
+
+	entered, err := readSecret("Vault passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if confirm && term.IsTerminal(int(os.Stdin.Fd())) {
+		again, err := readSecret("Confirm passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal([]byte(entered), []byte(again)) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	enteredPassphrase = []byte(entered)
+	return enteredPassphrase, nil
+}
+
+// checkVaultStrategies ensures every redaction strategy selected with
+// --redact and --redact-rule produces a replacement that identifies the
+// secret, so it can serve as the vault key
+func checkVaultStrategies() error {
+	names := []string{redactWith}
+	for _, rule := range redactRules {
+		_, name, _ := strings.Cut(rule, "=")
+		names = append(names, name)
+	}
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "placeholder", "fake":
+		default:
+			return fmt.Errorf("--vault requires the placeholder or fake redaction strategy, got %q", name)
+		}
+	}
+	return nil
+}
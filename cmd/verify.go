@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arnac-io/history-sanitizer/pkg/config"
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <placeholder>",
+	Short: "Check whether a secret corresponds to a placeholder",
+	Long: `Check whether a candidate secret is the value that was redacted into a
+placeholder such as [REDACTED_TOKEN_1a2b3c4d]. Placeholders are derived from
+your private key, so only the key holder can perform this check.
+
+A placeholder stands for the whole text its rule matched, context included:
+for "export API_KEY=abc123" that is "API_KEY=abc123", for a curl command
+"-u admin:hunter2", not just the secret value.
+
+The secret is read from stdin (without echo on a terminal) so that it does
+not end up in your shell history again.`,
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	green := color.New(color.FgGreen).SprintFunc()
+
+	key, err := config.ReadKey()
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return fmt.Errorf("no placeholder key stored, nothing can have been redacted with it (set %s to the key that was used)", config.KeyEnv)
+	}
+
+	secret, err := readSecret("Secret: ")
+	if err != nil {
+		return err
+	}
+
+	if !(sanitizer.Placeholder{Key: key}).Verify(args[0], secret) {
+		return fmt.Errorf("secret does not match placeholder %s", args[0])
+	}
+
+	fmt.Println(green("✓ Secret matches placeholder"))
+	return nil
+}
+
+// readSecret reads a single line from stdin, without echo when stdin is a
+// terminal
+func readSecret(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret: %w", err)
+		}
+		return string(data), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,381 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/config"
+	"github.com/arnac-io/history-sanitizer/pkg/safefile"
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+	"github.com/arnac-io/history-sanitizer/pkg/watch"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// unitName is the systemd user unit running watch
+const unitName = "history-sanitizer-watch.service"
+
+var (
+	watchFiles    []string
+	watchInterval time.Duration
+	watchPoll     bool
+	unitInstall   bool
+	unitEnvFile   string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Sanitize history files in the background as commands are appended",
+	Long: `Watch the history files and sanitize the commands appended to them, so
+secrets only stay in the history for a moment.
+
+Changes are detected with inotify on Linux and by polling elsewhere (or
+with --poll). Each file is first sanitized entirely, then only the entries
+appended since the last pass are scanned, as with --in-place --incremental:
+the shells' history lock is held while the file is rewritten, a backup is
+taken and the file is replaced atomically.
+
+The files are discovered when watch starts: pass -f for a history file
+that does not exist yet. Use "watch systemd" to run watch in the background
+as a systemd user service.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runWatch,
+}
+
+var watchSystemdCmd = &cobra.Command{
+	Use:   "systemd",
+	Short: "Print a systemd user unit running watch in the background",
+	Long: `Print a systemd user unit running watch with the flags given to this
+command, or write it to the systemd user directory with --install.
+
+  history-sanitizer watch systemd --redact env --install
+  systemctl --user daemon-reload
+  systemctl --user enable --now ` + unitName + `
+
+A service cannot be asked for a passphrase: --encrypt-backup requires
+--environment-file, a file only readable by you that sets
+` + vaultPassphraseEnv + `=<passphrase>. The unit loads it with
+EnvironmentFile=.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runWatchSystemd,
+}
+
+func init() {
+	// The flags are inherited by watch systemd, which passes them on to
+	// the unit's watch command
+	flags := watchCmd.PersistentFlags()
+	flags.StringArrayVarP(&watchFiles, "file", "f", nil, "History file to watch (repeatable, default: every known history file)")
+	flags.BoolVar(&watchPoll, "poll", false, "Poll the files instead of using inotify")
+	flags.DurationVar(&watchInterval, "interval", watch.DefaultInterval, "Polling interval")
+	flags.StringVar(&minSeverity, "min-severity", "low", "Only report findings at or above this severity (critical, high, medium, low)")
+	flags.StringVar(&redactLevel, "redact-severity", "low", "Only redact findings at or above this severity, warn about the rest")
+	flags.StringVar(&redactWith, "redact", "placeholder", "Redaction strategy: "+strings.Join(sanitizer.RedactorNames(), ", "))
+	flags.StringArrayVar(&redactRules, "redact-rule", nil, "Redaction strategy for a rule, as rule=strategy (repeatable)")
+	flags.StringVar(&shellName, "shell", "auto", "Shell that wrote the history, for --redact env (auto, bash, zsh, fish, powershell)")
+	flags.BoolVar(&encryptBak, "encrypt-backup", false, "Encrypt the backups with the vault passphrase, asked once when watch starts")
+	flags.IntVar(&backupKeep, "backup-keep", 10, "Number of backups kept per history file (0 keeps all)")
+	flags.StringVar(&backupAge, "backup-max-age", "", "Delete backups older than this, e.g. 30d (default: no limit)")
+	flags.StringVar(&baselineFile, "baseline", "", "Leave the findings of this baseline file alone")
+	flags.BoolVar(&useBaseline, "use-baseline", false, "Leave the findings of the baseline in the config directory alone")
+
+	watchSystemdCmd.Flags().BoolVar(&unitInstall, "install", false, "Write the unit to the systemd user directory instead of printing it")
+	watchSystemdCmd.Flags().StringVar(&unitEnvFile, "environment-file", "", "Environment file the unit loads, setting "+vaultPassphraseEnv+" for --encrypt-backup")
+
+	watchCmd.AddCommand(watchSystemdCmd)
+	rootCmd.AddCommand(watchCmd)
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	reportSeverity, err := scanner.ParseSeverity(minSeverity)
+	if err != nil {
+		return fmt.Errorf("invalid --min-severity: %w", err)
+	}
+	redactSeverity, err := scanner.ParseSeverity(redactLevel)
+	if err != nil {
+		return fmt.Errorf("invalid --redact-severity: %w", err)
+	}
+
+	files := watchFiles
+	if len(files) == 0 {
+		if files = existingHistoryFiles(); len(files) == 0 {
+			return fmt.Errorf("no history file found, pass one with -f")
+		}
+	}
+
+	// Every pass rewrites the history file in place from its checkpoint
+	inPlace, incremental = true, true
+
+	// Ask for the backup passphrase now, later passes reuse it
+	if encryptBak {
+		if _, err := readPassphrase(true); err != nil {
+			return err
+		}
+	}
+
+	w, err := watch.New(files, watch.Options{Interval: watchInterval, Poll: watchPoll})
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	method := w.Method
+	if method == watch.MethodPolling {
+		method = fmt.Sprintf("polling every %s", watchInterval)
+	}
+	fmt.Printf("Watching %d history file(s) (%s), press Ctrl+C to stop\n", len(files), method)
+	for _, path := range files {
+		fmt.Printf("  %s\n", path)
+	}
+
+	// Catch up with what was written before watch started, then retry
+	// the files that failed, e.g. because a shell held the lock
+	failed := make(map[string]bool)
+	pass := func(path string) {
+		if err := sanitizeAppended(os.Stdout, path, reportSeverity, redactSeverity); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", color.RedString("✗"), path, err)
+			failed[path] = true
+			return
+		}
+		delete(failed, path)
+	}
+	for _, path := range files {
+		if _, err := os.Stat(path); err == nil {
+			pass(path)
+		}
+	}
+
+	retry := time.NewTicker(watchInterval)
+	defer retry.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopped watching")
+			return nil
+		case err := <-w.Errors:
+			return fmt.Errorf("failed to watch history files: %w", err)
+		case path := <-w.Changes:
+			pass(path)
+		case <-retry.C:
+			for path := range failed {
+				pass(path)
+			}
+		}
+	}
+}
+
+// sanitizeAppended sanitizes the entries appended to a history file since
+// its sanitize checkpoint, like --in-place --incremental
+func sanitizeAppended(out io.Writer, path string, reportSeverity, redactSeverity scanner.Severity) error {
+	historyFile = path
+	scan, err := scanHistory(path, reportSeverity, checkpointSanitize, false)
+	if err != nil {
+		return err
+	}
+
+	opts, err := redactionOptions(scan.placeholder, scan.shell)
+	if err != nil {
+		return err
+	}
+	opts.MinSeverity = redactSeverity
+
+	redact := 0
+	for _, finding := range scan.findings {
+		action := "redact"
+		if opts.ShouldRedact(finding) {
+			redact++
+		} else {
+			action = "warn only"
+		}
+		fmt.Fprintf(out, "%s:%d  %s  %s  (%s)\n", path, finding.Line, severityColor(finding.Severity), finding.Type, action)
+	}
+
+	// Nothing to rewrite: move the checkpoint past the scanned entries
+	if redact == 0 {
+		return recordCheckpoint(checkpointSanitize, path, scan.content)
+	}
+	return writeSanitized(out, scan.content, scan.findings, opts, reportSeverity)
+}
+
+func runWatchSystemd(cmd *cobra.Command, args []string) error {
+	envFile, err := checkUnitEnvFile(unitEnvFile, encryptBak)
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the history-sanitizer binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	// Pass on the watch flags given on the command line
+	command := []string{exe, "watch"}
+	cmd.InheritedFlags().VisitAll(func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range s.GetSlice() {
+				// The service does not run from the current directory
+				if f.Name == "file" {
+					if abs, err := filepath.Abs(v); err == nil {
+						v = abs
+					}
+				}
+				command = append(command, "--"+f.Name+"="+v)
+			}
+			return
+		}
+		v := f.Value.String()
+		if f.Name == "baseline" {
+			if abs, err := filepath.Abs(v); err == nil {
+				v = abs
+			}
+		}
+		command = append(command, "--"+f.Name+"="+v)
+	})
+
+	var env []string
+	if dir := os.Getenv(config.DirEnv); dir != "" {
+		env = append(env, config.DirEnv+"="+dir)
+	}
+	unit := systemdUnit(command, env, envFile)
+
+	if !unitInstall {
+		fmt.Print(unit)
+		return nil
+	}
+
+	dir, err := systemdUserDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, unitName)
+	if err := safefile.WriteFile(path, []byte(unit), 0644); err != nil {
+		return fmt.Errorf("failed to write unit: %w", err)
+	}
+	fmt.Printf("%s Unit written to %s\n", color.GreenString("✓"), path)
+	fmt.Println("\nStart it now and at every login with:")
+	fmt.Println("  systemctl --user daemon-reload")
+	fmt.Printf("  systemctl --user enable --now %s\n", unitName)
+	return nil
+}
+
+// checkUnitEnvFile returns the absolute path of the --environment-file of
+// the unit. With --encrypt-backup, the file must set the passphrase, as
+// watch cannot prompt for it under systemd and would fail at every restart.
+func checkUnitEnvFile(path string, encrypt bool) (string, error) {
+	if path == "" {
+		if encrypt {
+			return "", fmt.Errorf("--encrypt-backup needs the passphrase in the service environment: "+
+				"write %s=<passphrase> to a file only you can read and pass it with --environment-file", vaultPassphraseEnv)
+		}
+		return "", nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("invalid --environment-file: %w", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("--environment-file %s is readable by other users, restrict it with chmod 600", abs)
+	}
+	if encrypt {
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			return "", fmt.Errorf("invalid --environment-file: %w", err)
+		}
+		if !setsVariable(string(data), vaultPassphraseEnv) {
+			return "", fmt.Errorf("--environment-file %s does not set %s", abs, vaultPassphraseEnv)
+		}
+	}
+	return abs, nil
+}
+
+// setsVariable reports whether a systemd environment file assigns name
+func setsVariable(data, name string) bool {
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "export "))
+		if value, ok := strings.CutPrefix(line, name+"="); ok && strings.Trim(value, `"'`) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// systemdUnit renders the user unit running command with the environment
+// variables env, and those of envFile if set
+func systemdUnit(command, env []string, envFile string) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Remove secrets from shell history as commands are written\n")
+	b.WriteString("Documentation=https://github.com/arnac-io/history-sanitizer\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	for _, e := range env {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(e))
+	}
+	if envFile != "" {
+		fmt.Fprintf(&b, "EnvironmentFile=%s\n", systemdQuote(envFile))
+	}
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = systemdQuote(arg)
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(quoted, " "))
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5\n")
+	// Backups and the vault key are only readable by the user
+	b.WriteString("UMask=0077\n")
+	b.WriteString("NoNewPrivileges=yes\n")
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=default.target\n")
+	return b.String()
+}
+
+// systemdQuote quotes a word of a unit file setting. Specifiers (%) and
+// variables ($) are escaped so the value is taken literally.
+func systemdQuote(s string) string {
+	s = strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'\\;") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// systemdUserDir returns the directory of the user's own systemd units
+func systemdUserDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "systemd", "user"), nil
+}
@@ -12,8 +12,10 @@ var version = "dev"
 
 func main() {
 	cmd.Version = version
-	if err := cmd.Execute(); err != nil {
+	err := cmd.Execute()
+	code := cmd.ExitCode(err)
+	if code == cmd.ExitError {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
 	}
+	os.Exit(code)
 }
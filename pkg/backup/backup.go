@@ -0,0 +1,258 @@
+// Package backup keeps timestamped copies of history files taken before
+// they are sanitized in place, optionally encrypted
+package backup
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/config"
+	"github.com/arnac-io/history-sanitizer/pkg/safefile"
+	"github.com/arnac-io/history-sanitizer/pkg/vault"
+)
+
+const (
+	metaSuffix = ".json"
+	dataSuffix = ".bak"
+
+	// idLayout is the timestamp part of backup IDs, sortable as text
+	idLayout = "20060102T150405Z"
+)
+
+// ErrNotFound is returned when no backup has the requested ID
+var ErrNotFound = errors.New("backup not found")
+
+// Backup describes a stored copy of a history file
+type Backup struct {
+	ID        string    `json:"id"`
+	Source    string    `json:"source"`
+	Created   time.Time `json:"created"`
+	Size      int       `json:"size"`
+	Encrypted bool      `json:"encrypted"`
+}
+
+// Store is a directory of backups. Each backup is a data file holding the
+// history, encrypted or not, and a JSON file describing it.
+type Store struct {
+	Dir string
+}
+
+// DefaultStore returns the store in the backups directory of the config
+// directory
+func DefaultStore() (Store, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return Store{}, err
+	}
+	return Store{Dir: filepath.Join(dir, "backups")}, nil
+}
+
+// Create stores data as a new backup of source. With a passphrase the data
+// is encrypted with vault.Seal.
+func (s Store) Create(source string, data, passphrase []byte) (Backup, error) {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return Backup{}, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if abs, err := filepath.Abs(source); err == nil {
+		source = abs
+	}
+
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return Backup{}, err
+	}
+	now := time.Now().UTC()
+	b := Backup{
+		ID:        now.Format(idLayout) + "-" + hex.EncodeToString(suffix),
+		Source:    source,
+		Created:   now,
+		Size:      len(data),
+		Encrypted: len(passphrase) > 0,
+	}
+
+	if b.Encrypted {
+		sealed, err := vault.Seal(data, passphrase)
+		if err != nil {
+			return Backup{}, fmt.Errorf("failed to encrypt backup: %w", err)
+		}
+		data = sealed
+	}
+
+	if err := safefile.WriteFile(s.dataPath(b.ID), data, 0600); err != nil {
+		return Backup{}, fmt.Errorf("failed to write backup: %w", err)
+	}
+	meta, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return Backup{}, err
+	}
+	if err := safefile.WriteFile(s.metaPath(b.ID), meta, 0600); err != nil {
+		os.Remove(s.dataPath(b.ID))
+		return Backup{}, fmt.Errorf("failed to write backup: %w", err)
+	}
+	return b, nil
+}
+
+// List returns every backup in the store, newest first
+func (s Store) List() ([]Backup, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), metaSuffix)
+		if !ok || e.IsDir() {
+			continue
+		}
+		b, err := s.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, b)
+	}
+
+	sortNewestFirst(backups)
+	return backups, nil
+}
+
+// Get returns the backup with the given ID
+func (s Store) Get(id string) (Backup, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return Backup{}, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	raw, err := os.ReadFile(s.metaPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return Backup{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return Backup{}, err
+	}
+
+	var b Backup
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return Backup{}, fmt.Errorf("failed to parse backup %s: %w", id, err)
+	}
+	b.ID = id
+	return b, nil
+}
+
+// Read returns the history stored in a backup, decrypting it with the
+// passphrase if it is encrypted
+func (s Store) Read(b Backup, passphrase []byte) ([]byte, error) {
+	data, err := os.ReadFile(s.dataPath(b.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup %s: %w", b.ID, err)
+	}
+	if !b.Encrypted {
+		return data, nil
+	}
+	return vault.Unseal(data, passphrase)
+}
+
+// Remove deletes a backup, overwriting its data first
+func (s Store) Remove(b Backup) error {
+	if err := safefile.Shred(s.dataPath(b.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.Remove(s.metaPath(b.ID))
+}
+
+// Paths returns the files making up a backup
+func (s Store) Paths(b Backup) []string {
+	return []string{s.dataPath(b.ID), s.metaPath(b.ID)}
+}
+
+// sortNewestFirst orders backups by creation time, most recent first
+func sortNewestFirst(backups []Backup) {
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+}
+
+func (s Store) dataPath(id string) string {
+	return filepath.Join(s.Dir, id+dataSuffix)
+}
+
+func (s Store) metaPath(id string) string {
+	return filepath.Join(s.Dir, id+metaSuffix)
+}
+
+// Retention limits how many backups of each history file are kept. Zero
+// values disable the corresponding limit.
+type Retention struct {
+	// Keep is the number of most recent backups kept per history file
+	Keep int
+	// MaxAge is the age after which backups are removed
+	MaxAge time.Duration
+}
+
+// Expired returns the backups the retention policy no longer keeps at the
+// given time
+func (r Retention) Expired(backups []Backup, now time.Time) []Backup {
+	sorted := make([]Backup, len(backups))
+	copy(sorted, backups)
+	sortNewestFirst(sorted)
+
+	var expired []Backup
+	kept := make(map[string]int)
+	for _, b := range sorted {
+		tooMany := r.Keep > 0 && kept[b.Source] >= r.Keep
+		tooOld := r.MaxAge > 0 && now.Sub(b.Created) > r.MaxAge
+		if tooMany || tooOld {
+			expired = append(expired, b)
+			continue
+		}
+		kept[b.Source]++
+	}
+	return expired
+}
+
+// Prune removes the backups the retention policy no longer keeps and
+// returns them
+func (s Store) Prune(r Retention) ([]Backup, error) {
+	backups, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	expired := r.Expired(backups, time.Now())
+	for _, b := range expired {
+		if err := s.Remove(b); err != nil {
+			return nil, fmt.Errorf("failed to remove backup %s: %w", b.ID, err)
+		}
+	}
+	return expired, nil
+}
+
+// ParseAge parses a retention age such as "30d", "12h" or "90m". Days are
+// accepted in addition to the units of time.ParseDuration.
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/vault"
+)
+
+func TestStore_CreateListRead(t *testing.T) {
+	s := Store{Dir: t.TempDir()}
+
+	first, err := s.Create("/home/u/.zsh_history", []byte("one\n"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := s.Create("/home/u/.zsh_history", []byte("two\n"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	backups, err := s.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(backups) != 2 || backups[0].ID != second.ID || backups[1].ID != first.ID {
+		t.Fatalf("expected newest first, got %+v", backups)
+	}
+
+	data, err := s.Read(backups[1], nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "one\n" {
+		t.Errorf("got %q", data)
+	}
+
+	if err := s.Remove(first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Get(first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after removal, got %v", err)
+	}
+}
+
+func TestStore_Encrypted(t *testing.T) {
+	s := Store{Dir: t.TempDir()}
+
+	b, err := s.Create("/h", []byte("export TOKEN=hunter2\n"), []byte("pass"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !b.Encrypted {
+		t.Error("expected backup to be marked encrypted")
+	}
+	for _, path := range s.Paths(b) {
+		raw, _ := os.ReadFile(path)
+		if strings.Contains(string(raw), "hunter2") {
+			t.Errorf("%s contains the plaintext secret", path)
+		}
+	}
+
+	if _, err := s.Read(b, []byte("wrong")); !errors.Is(err, vault.ErrWrongPassphrase) {
+		t.Errorf("expected ErrWrongPassphrase, got %v", err)
+	}
+	data, err := s.Read(b, []byte("pass"))
+	if err != nil || string(data) != "export TOKEN=hunter2\n" {
+		t.Errorf("got (%q, %v)", data, err)
+	}
+}
+
+func TestRetention_Expired(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	backup := func(source string, age time.Duration) Backup {
+		created := now.Add(-age)
+		return Backup{ID: created.Format(idLayout), Source: source, Created: created}
+	}
+	backups := []Backup{
+		backup("/a", 1*time.Hour),
+		backup("/a", 2*time.Hour),
+		backup("/a", 3*time.Hour),
+		backup("/b", 4*time.Hour),
+		backup("/b", 40*24*time.Hour),
+	}
+
+	expired := Retention{Keep: 2}.Expired(backups, now)
+	if len(expired) != 1 || expired[0].Source != "/a" || expired[0].Created != backups[2].Created {
+		t.Errorf("keep 2: unexpected expired backups %+v", expired)
+	}
+
+	expired = Retention{MaxAge: 30 * 24 * time.Hour}.Expired(backups, now)
+	if len(expired) != 1 || expired[0].Created != backups[4].Created {
+		t.Errorf("max age: unexpected expired backups %+v", expired)
+	}
+
+	if expired := (Retention{}).Expired(backups, now); len(expired) != 0 {
+		t.Errorf("zero retention should keep everything, got %+v", expired)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"30d", 30 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"xd", 0, true},
+		{"-1h", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAge(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAge(%q) = (%v, %v)", tt.input, got, err)
+		}
+	}
+}
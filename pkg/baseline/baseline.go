@@ -0,0 +1,141 @@
+// Package baseline records acknowledged findings by fingerprint, so later
+// scans only report what is new
+package baseline
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/config"
+	"github.com/arnac-io/history-sanitizer/pkg/report"
+	"github.com/arnac-io/history-sanitizer/pkg/safefile"
+)
+
+// formatVersion is the version of the baseline file layout
+const formatVersion = 1
+
+// Entry is an acknowledged finding. Only the fingerprint is used for
+// matching; the rest helps reviewing the baseline.
+type Entry struct {
+	Fingerprint string `json:"fingerprint"`
+	RuleID      string `json:"rule_id"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	// Preview is the command with the secret masked
+	Preview string `json:"preview"`
+}
+
+// Baseline is a set of acknowledged findings
+type Baseline struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	// KeyID identifies the placeholder key the fingerprints are keyed
+	// with, without revealing it
+	KeyID    string  `json:"key_id"`
+	Findings []Entry `json:"findings"`
+
+	index map[string]bool
+}
+
+// DefaultPath returns the baseline location in the config directory
+func DefaultPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "baseline.json"), nil
+}
+
+// KeyID returns the identifier of a placeholder key recorded in baselines:
+// an HMAC of a fixed message, so the key cannot be recovered from it
+func KeyID(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("baseline"))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// New builds a baseline acknowledging every finding of the reports, whose
+// fingerprints were keyed with key. A baseline only matches scans made with
+// the same key, which Load checks.
+func New(reports []report.Report, key []byte) *Baseline {
+	b := &Baseline{Version: formatVersion, Created: time.Now().UTC(), KeyID: KeyID(key), Findings: []Entry{}}
+	b.index = make(map[string]bool)
+	for _, r := range reports {
+		for _, f := range r.Findings {
+			if b.index[f.Fingerprint] {
+				continue
+			}
+			b.index[f.Fingerprint] = true
+			b.Findings = append(b.Findings, Entry{
+				Fingerprint: f.Fingerprint,
+				RuleID:      f.RuleID,
+				File:        r.File,
+				Line:        f.Line,
+				Preview:     f.Preview,
+			})
+		}
+	}
+	sort.SliceStable(b.Findings, func(i, j int) bool {
+		if b.Findings[i].File != b.Findings[j].File {
+			return b.Findings[i].File < b.Findings[j].File
+		}
+		return b.Findings[i].Line < b.Findings[j].Line
+	})
+	return b
+}
+
+// Load reads a baseline file created with the placeholder key key. A
+// baseline created with another key would silently match nothing, so it
+// is refused.
+func Load(path string, key []byte) (*Baseline, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("baseline not found: %s (create it with `baseline create`)", path)
+		}
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var b Baseline
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if b.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", b.Version, path)
+	}
+	if !hmac.Equal([]byte(b.KeyID), []byte(KeyID(key))) {
+		return nil, fmt.Errorf("baseline %s was created with a different placeholder key (share the key with %s)", path, config.KeyEnv)
+	}
+
+	b.index = make(map[string]bool, len(b.Findings))
+	for _, e := range b.Findings {
+		b.index[e.Fingerprint] = true
+	}
+	return &b, nil
+}
+
+// Save writes the baseline with owner-only permissions, as the previews
+// still show the commands around the secrets
+func (b *Baseline) Save(path string) error {
+	raw, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := safefile.WriteFile(path, append(raw, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Contains reports whether a fingerprint is acknowledged
+func (b *Baseline) Contains(fingerprint string) bool {
+	return b.index[fingerprint]
+}
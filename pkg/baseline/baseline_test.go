@@ -0,0 +1,83 @@
+package baseline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arnac-io/history-sanitizer/pkg/report"
+)
+
+var testKey = []byte("placeholder-key")
+
+func testReports() []report.Report {
+	return []report.Report{
+		{File: "/home/u/.zsh_history", Findings: []report.Finding{
+			{RuleID: "github-pat", Line: 3, Fingerprint: "aaaa", Preview: "git clone gh***90"},
+			{RuleID: "jwt", Line: 1, Fingerprint: "bbbb", Preview: "curl ey***5c"},
+		}},
+		{File: "/home/u/.bash_history", Findings: []report.Finding{
+			// The same secret in the same command is acknowledged once
+			{RuleID: "github-pat", Line: 7, Fingerprint: "aaaa", Preview: "git clone gh***90"},
+		}},
+	}
+}
+
+func TestBaseline_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+
+	b := New(testReports(), testKey)
+	if len(b.Findings) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(b.Findings))
+	}
+	if b.Findings[0].Line != 1 || b.Findings[1].Line != 3 {
+		t.Errorf("expected entries sorted by line, got %+v", b.Findings)
+	}
+	if err := b.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+
+	loaded, err := Load(path, testKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, fp := range []string{"aaaa", "bbbb"} {
+		if !loaded.Contains(fp) {
+			t.Errorf("expected %s to be acknowledged", fp)
+		}
+	}
+	if loaded.Contains("cccc") {
+		t.Error("unexpected fingerprint in baseline")
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Load(filepath.Join(dir, "missing.json"), testKey); err == nil || !strings.Contains(err.Error(), "baseline create") {
+		t.Errorf("expected a hint to create the baseline, got %v", err)
+	}
+
+	path := filepath.Join(dir, "future.json")
+	os.WriteFile(path, []byte(`{"version": 99, "findings": []}`), 0600)
+	if _, err := Load(path, testKey); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+
+	path = filepath.Join(dir, "baseline.json")
+	if err := New(testReports(), testKey).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, []byte("other-key")); err == nil || !strings.Contains(err.Error(), "different placeholder key") {
+		t.Errorf("expected a key mismatch error, got %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "placeholder-key") {
+		t.Error("the baseline should not contain the key")
+	}
+}
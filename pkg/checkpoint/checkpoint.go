@@ -0,0 +1,157 @@
+// Package checkpoint remembers how far each history file was scanned, so
+// later runs only scan the entries appended since
+package checkpoint
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/config"
+	"github.com/arnac-io/history-sanitizer/pkg/safefile"
+)
+
+const (
+	formatVersion = 1
+	// tailSize is how many bytes before the checkpoint are hashed to
+	// detect a rewritten file
+	tailSize = 4096
+)
+
+// Status tells how a scan relates to the checkpoint of a file
+type Status int
+
+const (
+	// StatusNew means the file has no checkpoint yet
+	StatusNew Status = iota
+	// StatusResumed means only the content after the checkpoint is new
+	StatusResumed
+	// StatusRewritten means the file was replaced or its scanned part
+	// changed, so it must be scanned in full
+	StatusRewritten
+	// StatusTruncated means the file is shorter than at the checkpoint
+	StatusTruncated
+)
+
+// State is the checkpoint of one file
+type State struct {
+	Inode uint64 `json:"inode"`
+	// Size is the offset up to which the file was scanned, always at the
+	// end of a line
+	Size int64 `json:"size"`
+	// TailHash is the SHA-256 of the bytes just before Size
+	TailHash string    `json:"tail_hash"`
+	Updated  time.Time `json:"updated"`
+}
+
+// file is the on-disk layout: checkpoints by purpose, then by path
+type file struct {
+	Version     int                         `json:"version"`
+	Checkpoints map[string]map[string]State `json:"checkpoints"`
+}
+
+// Store holds the checkpoints of every history file
+type Store struct {
+	path string
+	data file
+}
+
+// DefaultPath returns the checkpoint file in the config directory
+func DefaultPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "checkpoints.json"), nil
+}
+
+// Open loads the checkpoint file at path, or starts an empty one
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, data: file{Version: formatVersion, Checkpoints: make(map[string]map[string]State)}}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoints %s: %w", path, err)
+	}
+	if s.data.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version %d in %s", s.data.Version, path)
+	}
+	if s.data.Checkpoints == nil {
+		s.data.Checkpoints = make(map[string]map[string]State)
+	}
+	return s, nil
+}
+
+// Resume returns the offset of content to scan from for a purpose such as
+// "scan": the checkpoint if the file only grew since, 0 otherwise.
+func (s *Store) Resume(purpose, path string, content []byte, info os.FileInfo) (int, Status) {
+	state, ok := s.data.Checkpoints[purpose][key(path)]
+	switch {
+	case !ok:
+		return 0, StatusNew
+	case state.Inode != inode(info):
+		return 0, StatusRewritten
+	case int64(len(content)) < state.Size:
+		return 0, StatusTruncated
+	case tailHash(content, int(state.Size)) != state.TailHash:
+		return 0, StatusRewritten
+	}
+	return int(state.Size), StatusResumed
+}
+
+// Record sets the checkpoint of a file to the end of the last complete
+// line of content, which the caller has just scanned
+func (s *Store) Record(purpose, path string, content []byte, info os.FileInfo) {
+	size := bytes.LastIndexByte(content, '\n') + 1
+	if s.data.Checkpoints[purpose] == nil {
+		s.data.Checkpoints[purpose] = make(map[string]State)
+	}
+	s.data.Checkpoints[purpose][key(path)] = State{
+		Inode:    inode(info),
+		Size:     int64(size),
+		TailHash: tailHash(content, size),
+		Updated:  time.Now().UTC(),
+	}
+}
+
+// Save writes the checkpoints with owner-only permissions
+func (s *Store) Save() error {
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoints: %w", err)
+	}
+	if err := safefile.WriteFile(s.path, append(raw, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	return nil
+}
+
+// key identifies a file by its absolute path
+func key(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// tailHash hashes the bytes of content just before size
+func tailHash(content []byte, size int) string {
+	start := size - tailSize
+	if start < 0 {
+		start = 0
+	}
+	sum := sha256.Sum256(content[start:size])
+	return hex.EncodeToString(sum[:])
+}
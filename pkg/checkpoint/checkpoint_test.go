@@ -0,0 +1,94 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeHistory(t *testing.T, path, content string) ([]byte, os.FileInfo) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(content), info
+}
+
+func TestStore_Resume(t *testing.T) {
+	dir := t.TempDir()
+	history := filepath.Join(dir, "history")
+	store, err := Open(filepath.Join(dir, "checkpoints.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, info := writeHistory(t, history, "ls\ngit status\nvim")
+	if offset, status := store.Resume("scan", history, content, info); offset != 0 || status != StatusNew {
+		t.Errorf("got %d %v for a file without checkpoint", offset, status)
+	}
+	store.Record("scan", history, content, info)
+	if err := store.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Appended entries are scanned from the end of the last complete line
+	f, _ := os.OpenFile(history, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString(" notes.txt\nexport TOKEN=abc\n")
+	f.Close()
+	content, _ = os.ReadFile(history)
+	info, _ = os.Stat(history)
+
+	reopened, err := Open(filepath.Join(dir, "checkpoints.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	offset, status := reopened.Resume("scan", history, content, info)
+	if status != StatusResumed || string(content[offset:]) != "vim notes.txt\nexport TOKEN=abc\n" {
+		t.Errorf("got %v, resuming at %q", status, content[offset:])
+	}
+
+	// Checkpoints of different purposes are independent
+	if _, status := reopened.Resume("sanitize", history, content, info); status != StatusNew {
+		t.Errorf("expected no sanitize checkpoint, got %v", status)
+	}
+
+	// Rewriting the scanned part forces a full scan
+	content, info = writeHistory(t, history, "LS\ngit status\nvim notes.txt\n")
+	if offset, status := reopened.Resume("scan", history, content, info); offset != 0 || status != StatusRewritten {
+		t.Errorf("got %d %v for a rewritten file", offset, status)
+	}
+
+	content, info = writeHistory(t, history, "ls\n")
+	if offset, status := reopened.Resume("scan", history, content, info); offset != 0 || status != StatusTruncated {
+		t.Errorf("got %d %v for a truncated file", offset, status)
+	}
+}
+
+func TestStore_ReplacedFile(t *testing.T) {
+	dir := t.TempDir()
+	history := filepath.Join(dir, "history")
+	store, _ := Open(filepath.Join(dir, "checkpoints.json"))
+
+	content, info := writeHistory(t, history, "ls\n")
+	store.Record("scan", history, content, info)
+
+	// Same content, new file: the inode changed
+	other := filepath.Join(dir, "other")
+	writeHistory(t, other, "ls\nmore\n")
+	// Keep the old inode in use so the new file gets a different one
+	keep, _ := os.Open(history)
+	defer keep.Close()
+	if err := os.Rename(other, history); err != nil {
+		t.Fatal(err)
+	}
+	content, _ = os.ReadFile(history)
+	info, _ = os.Stat(history)
+
+	if _, status := store.Resume("scan", history, content, info); status != StatusRewritten && inode(info) != 0 {
+		t.Errorf("expected a replaced file to be rescanned, got %v", status)
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/safefile"
+)
+
+// allowlistFile is the name of the allowlist under Dir
+const allowlistFile = "allowlist.json"
+
+// AllowedSecret is a secret that is never reported, identified by its
+// keyed hash so the allowlist does not contain the secret itself
+type AllowedSecret struct {
+	Hash  string    `json:"hash"`
+	Rule  string    `json:"rule"`
+	Added time.Time `json:"added"`
+}
+
+// Allowlist holds the rules and secrets the user chose to always keep
+type Allowlist struct {
+	Rules   []string        `json:"rules"`
+	Secrets []AllowedSecret `json:"secrets"`
+}
+
+// AllowlistPath returns the location of the allowlist
+func AllowlistPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, allowlistFile), nil
+}
+
+// LoadAllowlist reads the allowlist, returning an empty one if none was
+// saved yet. It does not create the config directory.
+func LoadAllowlist() (*Allowlist, error) {
+	dir, err := dirPath()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, allowlistFile)
+
+	a := &Allowlist{}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allowlist: %w", err)
+	}
+	if err := json.Unmarshal(raw, a); err != nil {
+		return nil, fmt.Errorf("failed to parse allowlist %s: %w", path, err)
+	}
+	return a, nil
+}
+
+// Save writes the allowlist to the config directory
+func (a *Allowlist) Save() error {
+	path, err := AllowlistPath()
+	if err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode allowlist: %w", err)
+	}
+	if err := safefile.WriteFile(path, append(raw, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write allowlist: %w", err)
+	}
+	return nil
+}
+
+// AllowRule stops reporting every finding of a rule
+func (a *Allowlist) AllowRule(id string) {
+	if !a.AllowsRule(id) {
+		a.Rules = append(a.Rules, id)
+	}
+}
+
+// AllowSecret stops reporting a secret, by its keyed hash
+func (a *Allowlist) AllowSecret(hash, rule string) {
+	if !a.AllowsSecret(hash) {
+		a.Secrets = append(a.Secrets, AllowedSecret{Hash: hash, Rule: rule, Added: time.Now().UTC()})
+	}
+}
+
+// AllowsRule reports whether a rule is allowlisted
+func (a *Allowlist) AllowsRule(id string) bool {
+	return slices.Contains(a.Rules, id)
+}
+
+// AllowsSecret reports whether a secret hash is allowlisted
+func (a *Allowlist) AllowsSecret(hash string) bool {
+	for _, s := range a.Secrets {
+		if s.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAllowlist_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(DirEnv, dir)
+
+	a, err := LoadAllowlist()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.AllowsRule("jwt") || a.AllowsSecret("abcd") {
+		t.Fatal("expected an empty allowlist before saving")
+	}
+
+	a.AllowRule("jwt")
+	a.AllowRule("jwt")
+	a.AllowSecret("abcd", "github-pat")
+	if err := a.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, allowlistFile))
+	if err != nil {
+		t.Fatalf("expected allowlist file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+
+	loaded, err := LoadAllowlist()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded.Rules) != 1 || !loaded.AllowsRule("jwt") {
+		t.Errorf("unexpected rules %v", loaded.Rules)
+	}
+	if !loaded.AllowsSecret("abcd") || loaded.AllowsSecret("ef01") {
+		t.Errorf("unexpected secrets %v", loaded.Secrets)
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DirEnv overrides the configuration directory
+const DirEnv = "HISTORY_SANITIZER_CONFIG_DIR"
+
+// Dir returns the directory holding history-sanitizer's configuration and
+// keys, creating it with owner-only permissions if needed. It defaults to
+// history-sanitizer under the user's configuration directory
+// (e.g. ~/.config/history-sanitizer).
+func Dir() (string, error) {
+	dir, err := dirPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return dir, nil
+}
+
+// dirPath returns the configuration directory without creating it, for
+// reads that must not write anything
+func dirPath() (string, error) {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir, nil
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(base, "history-sanitizer"), nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// KeyEnv provides the placeholder key directly, as hex or raw text
+	KeyEnv = "HISTORY_SANITIZER_KEY"
+	// keyFile is the name of the generated key under Dir
+	keyFile = "placeholder.key"
+	keySize = 32
+)
+
+// KeyPath returns the location of the generated placeholder key
+func KeyPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, keyFile), nil
+}
+
+// LoadKey returns the per-user key used to derive placeholders. The key is
+// taken from $HISTORY_SANITIZER_KEY if set, otherwise read from the config
+// directory, generating a random one on first use.
+func LoadKey() ([]byte, error) {
+	key, err := ReadKey()
+	if err != nil || key != nil {
+		return key, err
+	}
+
+	path, err := KeyPath()
+	if err != nil {
+		return nil, err
+	}
+	if key, err = EphemeralKey(); err != nil {
+		return nil, err
+	}
+	// O_EXCL so a key generated concurrently by another run is never replaced
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return LoadKey()
+		}
+		return nil, fmt.Errorf("failed to store placeholder key: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		return nil, fmt.Errorf("failed to store placeholder key: %w", err)
+	}
+	return key, nil
+}
+
+// ReadKey returns the key of $HISTORY_SANITIZER_KEY or of the config
+// directory like LoadKey, but never generates nor stores one: it returns a
+// nil key when none exists yet.
+func ReadKey() ([]byte, error) {
+	if env := os.Getenv(KeyEnv); env != "" {
+		if decoded, err := hex.DecodeString(env); err == nil && len(decoded) >= 16 {
+			return decoded, nil
+		}
+		return []byte(env), nil
+	}
+
+	dir, err := dirPath()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, keyFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read placeholder key: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("invalid placeholder key in %s", path)
+	}
+	return key, nil
+}
+
+// EphemeralKey returns a random key, for runs that need one but must not
+// store it. Placeholders derived from it match no other run.
+func EphemeralKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate placeholder key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDir_Env(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested")
+	t.Setenv(DirEnv, dir)
+
+	got, err := Dir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != dir {
+		t.Errorf("Dir() = %s, want %s", got, dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		t.Fatalf("expected config directory to be created: %v", err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("expected config directory mode 0700, got %o", info.Mode().Perm())
+	}
+}
+
+func TestLoadKey_GeneratesAndPersists(t *testing.T) {
+	t.Setenv(DirEnv, t.TempDir())
+	t.Setenv(KeyEnv, "")
+
+	key, err := LoadKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(key) != keySize {
+		t.Errorf("expected %d byte key, got %d", keySize, len(key))
+	}
+
+	again, err := LoadKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Error("expected the generated key to be reused")
+	}
+
+	path, _ := KeyPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected key file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected key file mode 0600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestLoadKey_Env(t *testing.T) {
+	t.Setenv(DirEnv, t.TempDir())
+
+	t.Setenv(KeyEnv, "00112233445566778899aabbccddeeff")
+	key, err := LoadKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(key) != 16 || key[15] != 0xff {
+		t.Errorf("expected hex key to be decoded, got %x", key)
+	}
+
+	t.Setenv(KeyEnv, "correct horse battery staple")
+	key, err = LoadKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(key) != "correct horse battery staple" {
+		t.Errorf("expected raw key, got %q", key)
+	}
+}
+
+func TestReadKey_NeverWrites(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "config")
+	t.Setenv(DirEnv, dir)
+	t.Setenv(KeyEnv, "")
+
+	key, err := ReadKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != nil {
+		t.Errorf("expected no key before one is generated, got %x", key)
+	}
+	if _, err := LoadAllowlist(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the config directory not to be created, got %v", err)
+	}
+
+	generated, err := LoadKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key, err = ReadKey(); err != nil || !bytes.Equal(key, generated) {
+		t.Errorf("expected the stored key, got %x (%v)", key, err)
+	}
+}
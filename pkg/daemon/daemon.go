@@ -0,0 +1,195 @@
+// Package daemon is the protocol of the local scanning daemon: JSON
+// requests and responses, one per line, over a Unix domain socket
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/config"
+)
+
+// Operations of a request
+const (
+	// OpScan reports the findings of a command
+	OpScan = "scan"
+	// OpSanitize also returns the command with its findings redacted
+	OpSanitize = "sanitize"
+	// OpReloadConfig reloads the key and allowlist of the config directory
+	OpReloadConfig = "reload-config"
+)
+
+const (
+	// maxRequestSize bounds a request line, a command being far smaller
+	maxRequestSize = 1 << 20
+	// idleTimeout closes connections left without a request
+	idleTimeout = 30 * time.Second
+)
+
+// Request is a line sent by a client
+type Request struct {
+	Op      string `json:"op"`
+	Command string `json:"command,omitempty"`
+	// MinSeverity, Redact, RedactRules and Shell are the values of the
+	// check flags of the same names; empty uses their defaults
+	MinSeverity string   `json:"min_severity,omitempty"`
+	Redact      string   `json:"redact,omitempty"`
+	RedactRules []string `json:"redact_rules,omitempty"`
+	Shell       string   `json:"shell,omitempty"`
+}
+
+// Finding is a finding of a scanned command, with its secret masked
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Line     int    `json:"line"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Preview  string `json:"preview"`
+}
+
+// Response answers a request
+type Response struct {
+	OK       bool      `json:"ok"`
+	Error    string    `json:"error,omitempty"`
+	Findings []Finding `json:"findings,omitempty"`
+	// Redacted is the sanitized command, for OpSanitize
+	Redacted string `json:"redacted,omitempty"`
+}
+
+// Handler answers a request. It is called concurrently for requests of
+// different connections.
+type Handler func(Request) Response
+
+// DefaultSocket returns the socket path in the config directory, so
+// clients reach the daemon using the same key and allowlist
+func DefaultSocket() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
+
+// Listen creates the socket at path, readable by the user only. A socket
+// left behind by a daemon that is gone is replaced.
+func Listen(path string) (net.Listener, error) {
+	if _, err := os.Lstat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a daemon is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve answers the requests of the connections accepted on l until l is
+// closed, which returns nil. Open connections are not waited for: clients
+// fall back to scanning by themselves.
+func Serve(l net.Listener, handle Handler) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveConn(conn, handle)
+	}
+}
+
+// serveConn answers the requests of a connection, in order
+func serveConn(conn net.Conn, handle Handler) {
+	defer conn.Close()
+
+	in := bufio.NewScanner(conn)
+	in.Buffer(make([]byte, 0, 4096), maxRequestSize)
+	out := json.NewEncoder(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		if !in.Scan() {
+			return
+		}
+		var req Request
+		resp := Response{}
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			resp.Error = fmt.Sprintf("invalid request: %v", err)
+		} else {
+			resp = handle(req)
+		}
+		if err := out.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// Client is a connection to the daemon
+type Client struct {
+	conn    net.Conn
+	in      *bufio.Reader
+	timeout time.Duration
+}
+
+// Dial connects to the daemon listening on path. Each request must be
+// answered within timeout.
+func Dial(path string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, in: bufio.NewReader(conn), timeout: timeout}, nil
+}
+
+// Do sends a request and returns the response. A response with OK unset
+// is returned with an error holding its message.
+func (c *Client) Do(req Request) (*Response, error) {
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+
+	line, err := c.in.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if !resp.OK {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
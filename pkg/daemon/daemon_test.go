@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echo answers scan requests with the command in uppercase
+func echo(req Request) Response {
+	if req.Op != OpScan {
+		return Response{Error: "unknown op " + req.Op}
+	}
+	return Response{OK: true, Redacted: strings.ToUpper(req.Command)}
+}
+
+func testServer(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "daemon.sock")
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	done := make(chan error)
+	go func() { done <- Serve(l, echo) }()
+	t.Cleanup(func() {
+		l.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve returned %v", err)
+		}
+	})
+	return path
+}
+
+func TestClient_Do(t *testing.T) {
+	path := testServer(t)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected socket mode 0600, got %o", perm)
+	}
+
+	c, err := Dial(path, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer c.Close()
+
+	// Several requests share a connection
+	for _, command := range []string{"ls", "echo hi\nmultiline"} {
+		resp, err := c.Do(Request{Op: OpScan, Command: command})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Redacted != strings.ToUpper(command) {
+			t.Errorf("got %q, want %q", resp.Redacted, strings.ToUpper(command))
+		}
+	}
+
+	if _, err := c.Do(Request{Op: "explode"}); err == nil || !strings.Contains(err.Error(), "unknown op") {
+		t.Errorf("expected the handler's error, got %v", err)
+	}
+}
+
+func TestServe_InvalidRequest(t *testing.T) {
+	path := testServer(t)
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("{not json\n"))
+
+	buf := make([]byte, 512)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf[:n]), `"ok":false`) || !strings.Contains(string(buf[:n]), "invalid request") {
+		t.Errorf("unexpected response %s", buf[:n])
+	}
+}
+
+func TestListen_Socket(t *testing.T) {
+	path := testServer(t)
+
+	// A second daemon on the same socket is refused
+	if _, err := Listen(path); err == nil {
+		t.Error("expected an error while the daemon is running")
+	}
+
+	// A socket left by a daemon that is gone is replaced
+	stale := filepath.Join(t.TempDir(), "stale.sock")
+	l, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	l, err = Listen(stale)
+	if err != nil {
+		t.Fatalf("expected the stale socket to be replaced, got %v", err)
+	}
+	l.Close()
+}
+
+func TestDial_NoDaemon(t *testing.T) {
+	if _, err := Dial(filepath.Join(t.TempDir(), "missing.sock"), time.Second); err == nil {
+		t.Error("expected an error without a daemon")
+	}
+}
@@ -0,0 +1,217 @@
+// Package diff computes line-based unified diffs
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Op is the kind of change of a line
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Line is a line of an edit script
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Hunk is a group of changes with the unchanged lines around them
+type Hunk struct {
+	// FromLine and ToLine are the 1-based first lines of the hunk in the
+	// old and new text
+	FromLine, FromCount int
+	ToLine, ToCount     int
+	Lines               []Line
+}
+
+// Header returns the @@ line of the hunk
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", rangeOf(h.FromLine, h.FromCount), rangeOf(h.ToLine, h.ToCount))
+}
+
+// rangeOf formats a hunk range the way diff -u does
+func rangeOf(line, count int) string {
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// SplitLines splits text into the lines to diff. The newline ending the
+// last line does not start another, empty one.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Lines returns the shortest edit script turning a into b, using Myers'
+// algorithm. Memory grows with the square of the number of changes, not
+// with the size of the texts.
+func Lines(a, b []string) []Line {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	// trace[d] holds v[-d-1..d+1] as it was before step d
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the trace from the end of both texts to build the edit
+// script
+func backtrack(a, b []string, trace [][]int) []Line {
+	var script []Line
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			script = append(script, Line{Equal, a[x-1]})
+			x, y = x-1, y-1
+		}
+		if d > 0 {
+			if x == prevX {
+				script = append(script, Line{Insert, b[y-1]})
+			} else {
+				script = append(script, Line{Delete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(script)-1; i < j; i, j = i+1, j-1 {
+		script[i], script[j] = script[j], script[i]
+	}
+	return script
+}
+
+// Hunks groups the changes between a and b into hunks with context
+// unchanged lines around each change
+func Hunks(a, b []string, context int) []Hunk {
+	script := Lines(a, b)
+
+	var hunks []Hunk
+	for i := 0; i < len(script); {
+		// Find the next change and extend the hunk while changes are
+		// closer than twice the context
+		first := i
+		for first < len(script) && script[first].Op == Equal {
+			first++
+		}
+		if first == len(script) {
+			break
+		}
+		last := first
+		for j := first; j < len(script); j++ {
+			if script[j].Op == Equal {
+				continue
+			}
+			if j-last > 2*context {
+				break
+			}
+			last = j
+		}
+
+		start := first - context
+		if start < i {
+			start = i
+		}
+		end := last + 1 + context
+		if end > len(script) {
+			end = len(script)
+		}
+		hunks = append(hunks, newHunk(script, start, end))
+		i = end
+	}
+	return hunks
+}
+
+// newHunk builds the hunk of script[start:end]
+func newHunk(script []Line, start, end int) Hunk {
+	h := Hunk{Lines: script[start:end]}
+	for _, l := range script[:start] {
+		if l.Op != Insert {
+			h.FromLine++
+		}
+		if l.Op != Delete {
+			h.ToLine++
+		}
+	}
+	for _, l := range h.Lines {
+		if l.Op != Insert {
+			h.FromCount++
+		}
+		if l.Op != Delete {
+			h.ToCount++
+		}
+	}
+	// An empty range is numbered after the line it follows
+	if h.FromCount > 0 {
+		h.FromLine++
+	}
+	if h.ToCount > 0 {
+		h.ToLine++
+	}
+	return h
+}
+
+// WriteUnified writes the unified diff of a and b, labeled with the names
+// of the old and new texts
+func WriteUnified(w io.Writer, fromName, toName string, a, b []string, context int) error {
+	hunks := Hunks(a, b, context)
+	if len(hunks) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", fromName, toName); err != nil {
+		return err
+	}
+	for _, h := range hunks {
+		if _, err := fmt.Fprintln(w, h.Header()); err != nil {
+			return err
+		}
+		for _, l := range h.Lines {
+			if _, err := fmt.Fprintf(w, "%c%s\n", " -+"[l.Op], l.Text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package diff
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// apply rebuilds both texts from an edit script
+func apply(script []Line) (a, b []string) {
+	for _, l := range script {
+		if l.Op != Insert {
+			a = append(a, l.Text)
+		}
+		if l.Op != Delete {
+			b = append(b, l.Text)
+		}
+	}
+	return a, b
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		changes int
+	}{
+		{"a b c", "a b c", 0},
+		{"", "a b", 2},
+		{"a b", "", 2},
+		{"a b c d", "a x c d", 2},
+		{"a b c d e", "a c d e", 1},
+		{"a b c a b b a", "c b a b a c", 5},
+	}
+	for _, tt := range tests {
+		a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+		script := Lines(a, b)
+
+		gotA, gotB := apply(script)
+		if strings.Join(gotA, " ") != tt.a || strings.Join(gotB, " ") != tt.b {
+			t.Errorf("%q -> %q: script rebuilds %v -> %v", tt.a, tt.b, gotA, gotB)
+		}
+		changes := 0
+		for _, l := range script {
+			if l.Op != Equal {
+				changes++
+			}
+		}
+		if changes != tt.changes {
+			t.Errorf("%q -> %q: got %d changes, want %d", tt.a, tt.b, changes, tt.changes)
+		}
+	}
+}
+
+func TestWriteUnified(t *testing.T) {
+	var a []string
+	for i := 1; i <= 20; i++ {
+		a = append(a, "line"+string(rune('a'+i-1)))
+	}
+	b := append([]string(nil), a...)
+	b[1] = "changed"
+	b = append(b[:15], b[16:]...)
+
+	var out bytes.Buffer
+	if err := WriteUnified(&out, "old", "new", a, b, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ linea
+-lineb
++changed
+ linec
+ lined
+ linee
+@@ -13,7 +13,6 @@
+ linem
+ linen
+ lineo
+-linep
+ lineq
+ liner
+ lines
+`
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	WriteUnified(&out, "old", "new", a, a, 3)
+	if out.Len() != 0 {
+		t.Errorf("expected no output for identical texts, got %q", out.String())
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"ls\n", []string{"ls"}},
+		{"ls\npwd", []string{"ls", "pwd"}},
+		{"ls\n\n", []string{"ls", ""}},
+	}
+	for _, tt := range tests {
+		if got := SplitLines(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitLines(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	// A history ending with a newline has no trailing empty line in hunks
+	hunks := Hunks(SplitLines("a\nb\nsecret\n"), SplitLines("a\nb\n[REDACTED]\n"), 3)
+	if len(hunks) != 1 || hunks[0].FromCount != 3 || hunks[0].ToCount != 3 {
+		t.Errorf("unexpected hunks %+v", hunks)
+	}
+}
+
+func TestHunk_Header(t *testing.T) {
+	h := Hunks([]string{}, []string{"x"}, 3)[0]
+	if got := h.Header(); got != "@@ -0,0 +1 @@" {
+		t.Errorf("got %s", got)
+	}
+}
@@ -0,0 +1,181 @@
+package history
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format identifies the on-disk layout of a history file
+type Format string
+
+const (
+	FormatPlain           Format = "plain"
+	FormatZsh             Format = "zsh"
+	FormatZshExtended     Format = "zsh-extended"
+	FormatBash            Format = "bash"
+	FormatBashTimestamped Format = "bash-timestamped"
+	FormatFish            Format = "fish"
+	FormatPowerShell      Format = "powershell"
+)
+
+var (
+	// zshExtendedEntry matches `: <start>:<elapsed>;<command>`
+	zshExtendedEntry = regexp.MustCompile(`^: (\d+):\d+;(.*)$`)
+	// bashTimestamp matches the `#<epoch>` comment bash writes with HISTTIMEFORMAT
+	bashTimestamp = regexp.MustCompile(`^#(\d{9,})$`)
+	// fishCommand and fishWhen match the fields of a fish history entry
+	fishCommand = regexp.MustCompile(`^- cmd: (.*)$`)
+	fishWhen    = regexp.MustCompile(`^\s+when: (\d+)$`)
+)
+
+// DetectFormat determines the layout of a history written by shell,
+// looking at the content for the timestamped variants
+func DetectFormat(shell Shell, content string) Format {
+	switch shell {
+	case ShellZsh:
+		if zshExtendedLine.MatchString(content) {
+			return FormatZshExtended
+		}
+		return FormatZsh
+	case ShellBash:
+		if bashTimeLine.MatchString(content) {
+			return FormatBashTimestamped
+		}
+		return FormatBash
+	case ShellFish:
+		return FormatFish
+	case ShellPowerShell:
+		return FormatPowerShell
+	}
+	return FormatPlain
+}
+
+// Entry is a single command of a history file, which may span several
+// lines
+type Entry struct {
+	// Index is the 1-based position of the entry in the history
+	Index int
+	// StartLine and EndLine are the 1-based lines the entry covers,
+	// including timestamp lines
+	StartLine int
+	EndLine   int
+	// Command is the command text without the shell's metadata
+	Command string
+	// Timestamp is when the command was run, zero when not recorded
+	Timestamp time.Time
+}
+
+// ParseEntries splits a history in the given format into its entries.
+// Blank lines are not entries.
+func ParseEntries(content string, format Format) []Entry {
+	var entries []Entry
+	var pending time.Time
+	lines := strings.Split(content, "\n")
+
+	add := func(lineNum int, command string, ts time.Time) {
+		entries = append(entries, Entry{
+			Index:     len(entries) + 1,
+			StartLine: lineNum,
+			EndLine:   lineNum,
+			Command:   command,
+			Timestamp: ts,
+		})
+	}
+	// extend adds a line to the last entry, reporting whether there is one
+	extend := func(lineNum int, text string) bool {
+		if len(entries) == 0 {
+			return false
+		}
+		last := &entries[len(entries)-1]
+		last.EndLine = lineNum
+		if text != "" {
+			last.Command += "\n" + text
+		}
+		return true
+	}
+	continues := func(suffix string) bool {
+		return len(entries) > 0 && strings.HasSuffix(lines[entries[len(entries)-1].EndLine-1], suffix)
+	}
+
+	for i, line := range lines {
+		lineNum := i + 1
+
+		switch format {
+		case FormatZshExtended:
+			if m := zshExtendedEntry.FindStringSubmatch(line); m != nil {
+				add(lineNum, m[2], unixTime(m[1]))
+				continue
+			}
+			if continues("\\") && extend(lineNum, line) {
+				continue
+			}
+
+		case FormatZsh:
+			if continues("\\") && extend(lineNum, line) {
+				continue
+			}
+
+		case FormatPowerShell:
+			if continues("`") && extend(lineNum, line) {
+				continue
+			}
+
+		case FormatBashTimestamped:
+			if m := bashTimestamp.FindStringSubmatch(line); m != nil {
+				pending = unixTime(m[1])
+				continue
+			}
+			if strings.TrimSpace(line) != "" && !pending.IsZero() {
+				add(lineNum, line, pending)
+				entries[len(entries)-1].StartLine = lineNum - 1
+				pending = time.Time{}
+				continue
+			}
+
+		case FormatFish:
+			if m := fishCommand.FindStringSubmatch(line); m != nil {
+				add(lineNum, m[1], time.Time{})
+				continue
+			}
+			if m := fishWhen.FindStringSubmatch(line); m != nil && len(entries) > 0 {
+				entries[len(entries)-1].Timestamp = unixTime(m[1])
+				extend(lineNum, "")
+				continue
+			}
+			// paths: and its items belong to the current entry
+			if strings.HasPrefix(line, " ") && extend(lineNum, "") {
+				continue
+			}
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		add(lineNum, line, time.Time{})
+	}
+
+	return entries
+}
+
+// EntryAt returns the entry covering a 1-based line number
+func EntryAt(entries []Entry, line int) (Entry, bool) {
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].EndLine >= line
+	})
+	if i < len(entries) && entries[i].StartLine <= line {
+		return entries[i], true
+	}
+	return Entry{}, false
+}
+
+// unixTime parses a decimal epoch in seconds
+func unixTime(s string) time.Time {
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
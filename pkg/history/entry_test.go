@@ -0,0 +1,104 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		shell   Shell
+		content string
+		want    Format
+	}{
+		{ShellZsh, ": 1700000000:0;ls\n", FormatZshExtended},
+		{ShellZsh, "ls\n", FormatZsh},
+		{ShellBash, "#1700000000\nls\n", FormatBashTimestamped},
+		{ShellBash, "ls\n", FormatBash},
+		{ShellFish, "- cmd: ls\n", FormatFish},
+		{ShellPowerShell, "ls\n", FormatPowerShell},
+		{ShellUnknown, "ls\n", FormatPlain},
+	}
+
+	for _, tt := range tests {
+		if got := DetectFormat(tt.shell, tt.content); got != tt.want {
+			t.Errorf("DetectFormat(%q, %q) = %q, want %q", tt.shell, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestParseEntries(t *testing.T) {
+	ts := time.Unix(1700000000, 0).UTC()
+
+	tests := []struct {
+		name    string
+		format  Format
+		content string
+		want    []Entry
+	}{
+		{
+			name:    "zsh extended with continuation",
+			format:  FormatZshExtended,
+			content: ": 1700000000:0;ls\n: 1700000000:0;curl \\\n  -u a:b\n",
+			want: []Entry{
+				{Index: 1, StartLine: 1, EndLine: 1, Command: "ls", Timestamp: ts},
+				{Index: 2, StartLine: 2, EndLine: 3, Command: "curl \\\n  -u a:b", Timestamp: ts},
+			},
+		},
+		{
+			name:    "bash timestamped",
+			format:  FormatBashTimestamped,
+			content: "#1700000000\nls\n\n#1700000000\npwd",
+			want: []Entry{
+				{Index: 1, StartLine: 1, EndLine: 2, Command: "ls", Timestamp: ts},
+				{Index: 2, StartLine: 4, EndLine: 5, Command: "pwd", Timestamp: ts},
+			},
+		},
+		{
+			name:    "fish",
+			format:  FormatFish,
+			content: "- cmd: ls\n  when: 1700000000\n  paths:\n    - /tmp\n- cmd: pwd\n",
+			want: []Entry{
+				{Index: 1, StartLine: 1, EndLine: 4, Command: "ls", Timestamp: ts},
+				{Index: 2, StartLine: 5, EndLine: 5, Command: "pwd"},
+			},
+		},
+		{
+			name:    "powershell continuation",
+			format:  FormatPowerShell,
+			content: "Get-Item `\n  x\nls",
+			want: []Entry{
+				{Index: 1, StartLine: 1, EndLine: 2, Command: "Get-Item `\n  x"},
+				{Index: 2, StartLine: 3, EndLine: 3, Command: "ls"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseEntries(tt.content, tt.format)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("entry %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEntryAt(t *testing.T) {
+	entries := ParseEntries(": 1:0;a \\\nb\n: 2:0;c\n", FormatZshExtended)
+
+	if e, ok := EntryAt(entries, 2); !ok || e.Index != 1 {
+		t.Errorf("line 2: got (%+v, %v)", e, ok)
+	}
+	if e, ok := EntryAt(entries, 3); !ok || e.Index != 2 {
+		t.Errorf("line 3: got (%+v, %v)", e, ok)
+	}
+	if _, ok := EntryAt(entries, 10); ok {
+		t.Error("line 10: expected no entry")
+	}
+}
@@ -0,0 +1,70 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// KnownLocations returns the default history files of the supported
+// shells for the current user, plus $HISTFILE if set. The files may not
+// exist.
+func KnownLocations() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	locations := []string{
+		filepath.Join(home, ".zsh_history"),
+		filepath.Join(home, ".histfile"),
+		filepath.Join(home, ".bash_history"),
+		filepath.Join(home, ".sh_history"),
+		filepath.Join(home, ".local", "share", "fish", "fish_history"),
+		filepath.Join(home, ".local", "share", "powershell", "PSReadLine", "ConsoleHost_history.txt"),
+	}
+	if runtime.GOOS == "windows" {
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			locations = append(locations, filepath.Join(appData, "Microsoft", "Windows", "PowerShell", "PSReadLine", "ConsoleHost_history.txt"))
+		}
+	}
+	if histFile := os.Getenv("HISTFILE"); histFile != "" {
+		locations = append(locations, histFile)
+	}
+
+	seen := make(map[string]bool)
+	unique := locations[:0]
+	for _, l := range locations {
+		if !seen[l] {
+			seen[l] = true
+			unique = append(unique, l)
+		}
+	}
+	return unique
+}
+
+// leftoverSuffixes are the files next to a history that can hold an
+// unsanitized copy of it: outputs and backups of history-sanitizer and
+// its older versions, and the files zsh writes while saving history
+var leftoverSuffixes = []string{".backup", ".sanitized", ".restored", ".new", ".LOCK"}
+
+// Leftovers returns the existing files next to the history at path that
+// may hold unsanitized copies of it, including temporary files left by an
+// interrupted rewrite
+func Leftovers(path string) []string {
+	var found []string
+	for _, suffix := range leftoverSuffixes {
+		if info, err := os.Lstat(path + suffix); err == nil && !info.IsDir() {
+			found = append(found, path+suffix)
+		}
+	}
+
+	pattern := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if matches, err := filepath.Glob(pattern); err == nil {
+		found = append(found, matches...)
+	}
+
+	sort.Strings(found)
+	return found
+}
@@ -0,0 +1,51 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLeftovers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".zsh_history")
+	for _, name := range []string{
+		".zsh_history",
+		".zsh_history.backup",
+		".zsh_history.sanitized",
+		".zsh_history.LOCK",
+		"..zsh_history.123.tmp",
+		".bash_history.backup",
+		".zsh_history.old",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{
+		filepath.Join(dir, "..zsh_history.123.tmp"),
+		path + ".LOCK",
+		path + ".backup",
+		path + ".sanitized",
+	}
+	if got := Leftovers(path); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestKnownLocations_HistFile(t *testing.T) {
+	t.Setenv("HISTFILE", "/custom/history")
+
+	locations := KnownLocations()
+	found := false
+	for _, l := range locations {
+		if l == "/custom/history" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected $HISTFILE in %v", locations)
+	}
+}
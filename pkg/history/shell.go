@@ -0,0 +1,72 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Shell identifies the shell that wrote a history file
+type Shell string
+
+const (
+	ShellUnknown    Shell = ""
+	ShellBash       Shell = "bash"
+	ShellZsh        Shell = "zsh"
+	ShellFish       Shell = "fish"
+	ShellPowerShell Shell = "powershell"
+)
+
+// Shells lists the supported shells
+var Shells = []Shell{ShellBash, ShellZsh, ShellFish, ShellPowerShell}
+
+// ParseShell parses a shell name as accepted on the command line
+func ParseShell(name string) (Shell, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "auto":
+		return ShellUnknown, nil
+	case "bash", "sh":
+		return ShellBash, nil
+	case "zsh":
+		return ShellZsh, nil
+	case "fish":
+		return ShellFish, nil
+	case "powershell", "pwsh":
+		return ShellPowerShell, nil
+	}
+	return ShellUnknown, fmt.Errorf("unknown shell %q (expected bash, zsh, fish or powershell)", name)
+}
+
+var (
+	zshExtendedLine = regexp.MustCompile(`(?m)^: \d+:\d+;`)
+	fishEntryLine   = regexp.MustCompile(`(?m)^- cmd: `)
+	bashTimeLine    = regexp.MustCompile(`(?m)^#\d{9,}$`)
+)
+
+// DetectShell guesses which shell wrote a history file from its name and,
+// failing that, from its content. It returns ShellUnknown when neither is
+// conclusive.
+func DetectShell(path, content string) Shell {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.Contains(name, "zsh_history") || name == ".histfile":
+		return ShellZsh
+	case strings.Contains(name, "bash_history"):
+		return ShellBash
+	case strings.Contains(name, "fish_history"):
+		return ShellFish
+	case strings.Contains(name, "consolehost_history"):
+		return ShellPowerShell
+	}
+
+	switch {
+	case zshExtendedLine.MatchString(content):
+		return ShellZsh
+	case fishEntryLine.MatchString(content):
+		return ShellFish
+	case bashTimeLine.MatchString(content):
+		return ShellBash
+	}
+	return ShellUnknown
+}
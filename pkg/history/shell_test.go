@@ -0,0 +1,41 @@
+package history
+
+import "testing"
+
+func TestDetectShell(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    Shell
+	}{
+		{"zsh by name", "/home/u/.zsh_history", "", ShellZsh},
+		{"bash by name", "/home/u/.bash_history", "", ShellBash},
+		{"fish by name", "/home/u/.local/share/fish/fish_history", "", ShellFish},
+		{"powershell by name", `C:\Users\u\AppData\Roaming\Microsoft\Windows\PowerShell\PSReadLine\ConsoleHost_history.txt`, "", ShellPowerShell},
+		{"zsh by content", "history.txt", ": 1738075152:0;ls -la\n", ShellZsh},
+		{"fish by content", "history.txt", "- cmd: ls -la\n  when: 1738075152\n", ShellFish},
+		{"bash by content", "history.txt", "#1738075152\nls -la\n", ShellBash},
+		{"unknown", "history.txt", "ls -la\n", ShellUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectShell(tt.path, tt.content); got != tt.want {
+				t.Errorf("DetectShell() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseShell(t *testing.T) {
+	for name, want := range map[string]Shell{"auto": ShellUnknown, "ZSH": ShellZsh, "pwsh": ShellPowerShell, "sh": ShellBash} {
+		got, err := ParseShell(name)
+		if err != nil || got != want {
+			t.Errorf("ParseShell(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseShell("tcsh"); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
@@ -0,0 +1,97 @@
+// Package hook renders the shell snippets that check each command line
+// before the shell saves it to its history
+package hook
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+)
+
+// What a hook does with a command containing secrets
+const (
+	// ModeRedact saves the command with its secrets redacted
+	ModeRedact = "redact"
+	// ModeReject leaves the command out of the history
+	ModeReject = "reject"
+)
+
+// Shells lists the shells with a hook
+var Shells = []history.Shell{history.ShellZsh, history.ShellBash, history.ShellFish}
+
+//go:embed scripts
+var scripts embed.FS
+
+var templates = template.Must(template.ParseFS(scripts, "scripts/hook.*"))
+
+// Options configures a hook
+type Options struct {
+	// Command runs the check subcommand: the executable, "check" and its
+	// flags. The hook writes the command line to its stdin, never to its
+	// arguments, which any local user can read while it runs.
+	Command []string
+	// Mode is ModeRedact or ModeReject
+	Mode string
+}
+
+// Script returns the hook of a shell. The hook pipes each command line to
+// the check command and only acts when it exits with 1, the command holding
+// secrets: any other status, errors included, keeps the command, so a
+// broken install never loses history.
+func Script(shell history.Shell, opts Options) (string, error) {
+	quote := shQuote
+	switch shell {
+	case history.ShellZsh, history.ShellBash:
+	case history.ShellFish:
+		quote = fishQuote
+	default:
+		return "", fmt.Errorf("no hook for shell %q (expected zsh, bash or fish)", shell)
+	}
+	if opts.Mode != ModeRedact && opts.Mode != ModeReject {
+		return "", fmt.Errorf("unknown hook mode %q (expected %s or %s)", opts.Mode, ModeRedact, ModeReject)
+	}
+	if len(opts.Command) == 0 {
+		return "", fmt.Errorf("no check command")
+	}
+
+	words := make([]string, len(opts.Command))
+	for i, w := range opts.Command {
+		words[i] = quote(w)
+	}
+	data := struct {
+		Check string
+		Mode  string
+	}{strings.Join(words, " "), opts.Mode}
+
+	var b strings.Builder
+	if err := templates.ExecuteTemplate(&b, "hook."+string(shell), data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// shQuote quotes a word for bash and zsh, unless it only holds characters
+// without special meaning
+func shQuote(s string) string {
+	if plain(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes a word for fish, where backslashes and single quotes
+// are escaped inside single quotes
+func fishQuote(s string) string {
+	if plain(s) {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// plain reports whether a word needs no quoting in any of the shells
+func plain(s string) bool {
+	return s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_+/.,:@") == ""
+}
@@ -0,0 +1,163 @@
+package hook
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+)
+
+var testCommand = []string{"/opt/my tools/history-sanitizer", "check", "--shell", "zsh"}
+
+func TestScript(t *testing.T) {
+	tests := []struct {
+		shell  history.Shell
+		check  string
+		redact string
+	}{
+		{history.ShellZsh, `print -rn -- "$line" | '/opt/my tools/history-sanitizer' check --shell zsh 2>`, "print -sr"},
+		{history.ShellBash, `printf '%s' "$line" | '/opt/my tools/history-sanitizer' check --shell zsh 2>`, "history -s"},
+		{history.ShellFish, `printf '%s' $argv[1] | '/opt/my tools/history-sanitizer' check --shell zsh 2>`, "history append"},
+	}
+	for _, tt := range tests {
+		script, err := Script(tt.shell, Options{Command: testCommand, Mode: ModeRedact})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.shell, err)
+		}
+		if !strings.Contains(script, tt.check) {
+			t.Errorf("%s: expected the check command %s in:\n%s", tt.shell, tt.check, script)
+		}
+		if !strings.Contains(script, tt.redact) {
+			t.Errorf("%s: expected %s to store the redacted command", tt.shell, tt.redact)
+		}
+
+		// Rejecting never stores the command
+		script, err = Script(tt.shell, Options{Command: testCommand, Mode: ModeReject})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.shell, err)
+		}
+		if strings.Contains(script, tt.redact) {
+			t.Errorf("%s: reject hook stores the command:\n%s", tt.shell, script)
+		}
+	}
+}
+
+func TestScript_Syntax(t *testing.T) {
+	for _, shell := range Shells {
+		path, err := exec.LookPath(string(shell))
+		if err != nil {
+			continue
+		}
+		for _, mode := range []string{ModeRedact, ModeReject} {
+			script, err := Script(shell, Options{Command: testCommand, Mode: mode})
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", shell, err)
+			}
+			cmd := exec.Command(path, "-n")
+			cmd.Stdin = strings.NewReader(script)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("%s %s hook does not parse: %v\n%s", shell, mode, err, out)
+			}
+		}
+	}
+}
+
+// stubCheck writes a check command standing in for history-sanitizer: it
+// redacts "hunter22" from the command read on stdin and logs its arguments
+func stubCheck(t *testing.T) (command []string, argvLog string) {
+	t.Helper()
+	dir := t.TempDir()
+	argvLog = filepath.Join(dir, "argv")
+	stub := filepath.Join(dir, "check")
+	script := `#!/bin/sh
+printf '%s\n' "$*" >> '` + argvLog + `'
+line=$(cat)
+case $line in
+*hunter22*) printf '%s\n' "$line" | sed 's/hunter22/[REDACTED]/'; exit 1 ;;
+esac
+printf '%s\n' "$line"
+`
+	if err := os.WriteFile(stub, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return []string{stub, "check", "--shell", "bash"}, argvLog
+}
+
+func TestScript_Bash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{ModeRedact, []string{"ls -la", "mysql -p[REDACTED] -u root", "echo done"}},
+		{ModeReject, []string{"ls -la", "echo done"}},
+	}
+	for _, tt := range tests {
+		command, argvLog := stubCheck(t)
+		script, err := Script(history.ShellBash, Options{Command: command, Mode: tt.mode})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Each history -s stands for a command entered at the prompt. The
+		// driver is on one line, so only the history -s entries are saved.
+		driver := script + `
+HISTFILE=/dev/null; set -o history; for c in 'ls -la' 'mysql -phunter22 -u root' 'echo done'; do history -s "$c"; __history_sanitizer_hook; done; set +o history
+HISTTIMEFORMAT= history | sed 's/^ *[0-9]* *//'
+`
+		out, err := exec.Command(bash, "--norc", "--noprofile", "-c", driver).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", tt.mode, err, out)
+		}
+		if got := strings.Split(strings.TrimSpace(string(out)), "\n"); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: history is %q, want %q", tt.mode, got, tt.want)
+		}
+
+		argv, err := os.ReadFile(argvLog)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Count(string(argv), "\n") != 3 {
+			t.Errorf("%s: expected a check per command, got:\n%s", tt.mode, argv)
+		}
+		if strings.Contains(string(argv), "hunter22") || strings.Contains(string(argv), "ls -la") {
+			t.Errorf("%s: the command line was passed as an argument:\n%s", tt.mode, argv)
+		}
+	}
+}
+
+func TestScript_Errors(t *testing.T) {
+	if _, err := Script(history.ShellPowerShell, Options{Command: testCommand, Mode: ModeRedact}); err == nil {
+		t.Error("expected an error for a shell without hook")
+	}
+	if _, err := Script(history.ShellZsh, Options{Command: testCommand, Mode: "drop"}); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		word, sh, fish string
+	}{
+		{"/usr/local/bin/history-sanitizer", "/usr/local/bin/history-sanitizer", "/usr/local/bin/history-sanitizer"},
+		{"it's", `'it'\''s'`, `'it\'s'`},
+		{`C:\bin`, `'C:\bin'`, `'C:\\bin'`},
+		{"$HOME", "'$HOME'", "'$HOME'"},
+		{"", "''", "''"},
+	}
+	for _, tt := range tests {
+		if got := shQuote(tt.word); got != tt.sh {
+			t.Errorf("shQuote(%q) = %s, want %s", tt.word, got, tt.sh)
+		}
+		if got := fishQuote(tt.word); got != tt.fish {
+			t.Errorf("fishQuote(%q) = %s, want %s", tt.word, got, tt.fish)
+		}
+	}
+}
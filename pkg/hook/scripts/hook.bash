@@ -0,0 +1,33 @@
+# history-sanitizer: keep secrets out of the bash history.
+# Load it from ~/.bashrc with: eval "$(history-sanitizer init bash)"
+#
+# bash has no hook deciding whether a command is saved, and HISTIGNORE
+# only holds fixed patterns, so the last entry is checked from
+# PROMPT_COMMAND and deleted with history -d before anything writes the
+# history file.
+__history_sanitizer_hook() {
+  local status=$? entry num line redacted
+  entry=$(HISTTIMEFORMAT= builtin history 1)
+  [[ $entry =~ ^\ *([0-9]+)\*?\ +(.*)$ ]] || return $status
+  num=${BASH_REMATCH[1]} line=${BASH_REMATCH[2]}
+  # An empty command line leaves the last entry unchanged
+  [[ $num == "$__history_sanitizer_last" ]] && return $status
+  __history_sanitizer_last=$num
+
+  redacted=$(builtin printf '%s' "$line" | {{.Check}} 2>/dev/null)
+  if (( $? == 1 )); then
+    builtin history -d "$num"
+{{- if eq .Mode "redact"}}
+    # Store the redacted command instead
+    builtin history -s -- "$redacted"
+{{- else}}
+    # The next command gets the number of the deleted one
+    __history_sanitizer_last=$((num - 1))
+{{- end}}
+  fi
+  return $status
+}
+# Run first, so a history -a of PROMPT_COMMAND only sees the result
+if [[ ";${PROMPT_COMMAND[0]};" != *";__history_sanitizer_hook;"* ]]; then
+  PROMPT_COMMAND="__history_sanitizer_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
@@ -0,0 +1,15 @@
+# history-sanitizer: keep secrets out of the fish history.
+# Load it from ~/.config/fish/config.fish with: history-sanitizer init fish | source
+function fish_should_add_to_history
+    # Keep fish's default of not saving commands starting with a space
+    string match -qr '^\s' -- $argv[1]; and return 1
+
+    set -l redacted (builtin printf '%s' $argv[1] | {{.Check}} 2>/dev/null)
+    # Keep the command unless it holds secrets
+    test $status -eq 1; or return 0
+{{- if eq .Mode "redact"}}
+    # Store the redacted command instead, where fish has history append
+    builtin history append -- (string join \n -- $redacted) 2>/dev/null
+{{- end}}
+    return 1
+end
@@ -0,0 +1,205 @@
+package report
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+)
+
+// Audit report formats, chosen from the extension of the report file
+const (
+	AuditHTML     = "html"
+	AuditMarkdown = "md"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("report.html.tmpl").Funcs(htmltemplate.FuncMap{
+		"datetime": datetime,
+		"percent":  percent,
+	}).ParseFS(templates, "templates/report.html.tmpl"))
+
+	markdownTemplate = texttemplate.Must(texttemplate.New("report.md.tmpl").Funcs(texttemplate.FuncMap{
+		"datetime": datetime,
+		"percent":  percent,
+		"code":     markdownCode,
+		"cell":     markdownCell,
+	}).ParseFS(templates, "templates/report.md.tmpl"))
+)
+
+// AuditFormat returns the audit report format for a report file name
+func AuditFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return AuditHTML, nil
+	case ".md", ".markdown":
+		return AuditMarkdown, nil
+	}
+	return "", fmt.Errorf("unsupported report file %q (expected .html or .md)", path)
+}
+
+// WriteAudit writes a self-contained, human-readable audit report of one
+// or more scans in the given audit format
+func WriteAudit(w io.Writer, format string, reports []Report) error {
+	data := newAuditData(reports)
+	switch format {
+	case AuditHTML:
+		return htmlTemplate.Execute(w, data)
+	case AuditMarkdown:
+		return markdownTemplate.Execute(w, data)
+	}
+	return fmt.Errorf("unknown audit report format %q", format)
+}
+
+// auditData is what the audit templates render
+type auditData struct {
+	GeneratedAt time.Time
+	Version     string
+	Total       int
+	BySeverity  []severityCount
+	ByRule      []ruleCount
+	ByFile      []fileCount
+	Timeline    []auditFinding
+	// Undated counts the findings in entries without a timestamp
+	Undated  int
+	Findings []auditFinding
+}
+
+type severityCount struct {
+	Severity string
+	Count    int
+}
+
+type ruleCount struct {
+	RuleID      string
+	Description string
+	Severity    string
+	Count       int
+}
+
+type fileCount struct {
+	File    string
+	Format  history.Format
+	Count   int
+	Highest string
+}
+
+type auditFinding struct {
+	File string
+	Finding
+}
+
+// newAuditData aggregates the findings of the reports
+func newAuditData(reports []Report) auditData {
+	data := auditData{GeneratedAt: time.Now().UTC()}
+	severities := make(map[string]int)
+	rules := make(map[string]*ruleCount)
+
+	for _, r := range reports {
+		data.Version = r.Tool.Version
+		file := fileCount{File: r.File, Format: r.Format, Count: len(r.Findings), Highest: "-"}
+		highest := scanner.SeverityUnknown
+
+		for _, f := range r.Findings {
+			data.Total++
+			severities[f.Severity]++
+
+			rc, ok := rules[f.RuleID]
+			if !ok {
+				rc = &ruleCount{RuleID: f.RuleID, Description: f.Description, Severity: f.Severity}
+				rules[f.RuleID] = rc
+			}
+			rc.Count++
+
+			if s, err := scanner.ParseSeverity(f.Severity); err == nil && s > highest {
+				highest = s
+				file.Highest = f.Severity
+			}
+
+			af := auditFinding{File: r.File, Finding: f}
+			data.Findings = append(data.Findings, af)
+			if f.Timestamp != nil {
+				data.Timeline = append(data.Timeline, af)
+			} else {
+				data.Undated++
+			}
+		}
+		data.ByFile = append(data.ByFile, file)
+	}
+
+	for _, s := range []scanner.Severity{scanner.SeverityCritical, scanner.SeverityHigh, scanner.SeverityMedium, scanner.SeverityLow} {
+		if n := severities[s.String()]; n > 0 {
+			data.BySeverity = append(data.BySeverity, severityCount{Severity: s.String(), Count: n})
+		}
+	}
+	for _, rc := range rules {
+		data.ByRule = append(data.ByRule, *rc)
+	}
+	sort.Slice(data.ByRule, func(i, j int) bool {
+		if data.ByRule[i].Count != data.ByRule[j].Count {
+			return data.ByRule[i].Count > data.ByRule[j].Count
+		}
+		return data.ByRule[i].RuleID < data.ByRule[j].RuleID
+	})
+	sort.SliceStable(data.Timeline, func(i, j int) bool {
+		return data.Timeline[i].Timestamp.Before(*data.Timeline[j].Timestamp)
+	})
+
+	return data
+}
+
+// datetime formats a time for the reports
+func datetime(t any) string {
+	switch v := t.(type) {
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05 UTC")
+	case *time.Time:
+		if v != nil {
+			return v.UTC().Format("2006-01-02 15:04:05 UTC")
+		}
+	}
+	return "-"
+}
+
+// percent formats a confidence between 0 and 1
+func percent(f float64) string {
+	return fmt.Sprintf("%.0f%%", f*100)
+}
+
+// markdownCode renders s as inline code that survives backticks and table
+// pipes in the command
+func markdownCode(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\n", " ")
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	var b bytes.Buffer
+	b.WriteString(fence)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		b.WriteString(" " + s + " ")
+	} else {
+		b.WriteString(s)
+	}
+	b.WriteString(fence)
+	return b.String()
+}
+
+// markdownCell escapes text for a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// gitleaksRedacted is what Gitleaks writes in place of secrets with --redact
+const gitleaksRedacted = "REDACTED"
+
+// gitleaksFinding mirrors the fields of a finding in Gitleaks v8 JSON
+// reports, which are encoded under their Go names
+type gitleaksFinding struct {
+	Description string
+	StartLine   int
+	EndLine     int
+	StartColumn int
+	EndColumn   int
+	Match       string
+	Secret      string
+	File        string
+	SymlinkFile string
+	Commit      string
+	Entropy     float32
+	Author      string
+	Email       string
+	Date        string
+	Message     string
+	Tags        []string
+	RuleID      string
+	Fingerprint string
+}
+
+// WriteGitleaks writes the findings as a Gitleaks JSON report, so pipelines
+// parsing Gitleaks output treat history scans like repository scans. As
+// with `gitleaks --redact`, secrets are replaced by REDACTED unless the
+// report includes them. Fingerprints follow Gitleaks' file:rule:line
+// convention for scans outside git.
+func WriteGitleaks(w io.Writer, r Report) error {
+	findings := make([]gitleaksFinding, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		match, secret := f.match, f.secret
+		if f.Secret == "" {
+			match = strings.ReplaceAll(match, secret, gitleaksRedacted)
+			secret = gitleaksRedacted
+		}
+
+		g := gitleaksFinding{
+			Description: f.Description,
+			StartLine:   f.Line,
+			EndLine:     f.Line,
+			StartColumn: f.Column,
+			EndColumn:   f.EndColumn,
+			Match:       match,
+			Secret:      secret,
+			File:        r.File,
+			Entropy:     float32(f.Entropy),
+			Tags:        []string{},
+			RuleID:      f.RuleID,
+			Fingerprint: fmt.Sprintf("%s:%s:%d", r.File, f.RuleID, f.Line),
+		}
+		if f.Timestamp != nil {
+			g.Date = f.Timestamp.UTC().Format(time.RFC3339)
+		}
+		findings = append(findings, g)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(findings)
+}
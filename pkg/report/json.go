@@ -0,0 +1,37 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+)
+
+// WriteJSON writes the report as a single indented JSON document
+func WriteJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Record is a finding as written in JSON Lines reports, carrying the
+// report fields needed to interpret it on its own
+type Record struct {
+	SchemaVersion string         `json:"schema_version"`
+	File          string         `json:"file"`
+	Format        history.Format `json:"format"`
+	Finding
+}
+
+// WriteJSONL writes one JSON object per finding and line, for streaming
+// into log pipelines
+func WriteJSONL(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	for _, f := range r.Findings {
+		rec := Record{SchemaVersion: r.SchemaVersion, File: r.File, Format: r.Format, Finding: f}
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
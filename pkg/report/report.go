@@ -0,0 +1,208 @@
+// Package report builds machine-readable reports of the secrets found in a
+// history file
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+)
+
+// SchemaVersion is the version of the JSON report schema. It changes
+// whenever a field is removed or changes meaning; fields may be added
+// without a version change.
+const SchemaVersion = "1"
+
+// ToolName identifies history-sanitizer in reports
+const ToolName = "history-sanitizer"
+
+// Output formats
+const (
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	// FormatSARIF is the SARIF 2.1.0 format of code-scanning dashboards
+	FormatSARIF = "sarif"
+	// FormatGitleaks is the JSON report format of Gitleaks
+	FormatGitleaks = "gitleaks"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatJSON, FormatJSONL, FormatSARIF, FormatGitleaks}
+
+// Write encodes a report in the given output format
+func Write(w io.Writer, format string, r Report) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, r)
+	case FormatJSONL:
+		return WriteJSONL(w, r)
+	case FormatSARIF:
+		return WriteSARIF(w, r)
+	case FormatGitleaks:
+		return WriteGitleaks(w, r)
+	}
+	return fmt.Errorf("unknown report format %q (expected one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// Report lists the findings of a scan of one history file
+type Report struct {
+	SchemaVersion string         `json:"schema_version"`
+	Tool          Tool           `json:"tool"`
+	File          string         `json:"file"`
+	Format        history.Format `json:"format"`
+	GeneratedAt   time.Time      `json:"generated_at"`
+	Summary       Summary        `json:"summary"`
+	Findings      []Finding      `json:"findings"`
+	// Rules is the active rule set, for formats that describe every rule
+	Rules []scanner.Rule `json:"-"`
+}
+
+// Tool identifies the program that produced a report
+type Tool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Summary counts the findings of a report
+type Summary struct {
+	Total      int            `json:"total"`
+	BySeverity map[string]int `json:"by_severity"`
+	// Suppressed counts the findings left out of the report
+	Suppressed Suppressed `json:"suppressed"`
+}
+
+// Suppressed counts findings acknowledged by an inline allow marker, the
+// allowlist or a baseline
+type Suppressed struct {
+	Inline    int `json:"inline"`
+	Allowlist int `json:"allowlist"`
+	Baseline  int `json:"baseline"`
+}
+
+// Finding is a secret found in the history. The raw secret is only
+// included when explicitly requested.
+type Finding struct {
+	RuleID      string   `json:"rule_id"`
+	RuleIDs     []string `json:"rule_ids"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Confidence  float64  `json:"confidence"`
+	Validated   bool     `json:"validated"`
+	// Entry is the 1-based index of the history entry holding the secret
+	Entry int `json:"entry"`
+	// Timestamp is when the command was run, if the history records it
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	// Line and Column are 1-based; EndColumn is the last column of the match
+	Line      int     `json:"line"`
+	Column    int     `json:"column"`
+	EndColumn int     `json:"end_column"`
+	Preview   string  `json:"preview"`
+	Entropy   float64 `json:"entropy"`
+	// Fingerprint identifies the same secret in the same command across
+	// scans, independently of its position in the file
+	Fingerprint string `json:"fingerprint"`
+	Match       string `json:"match,omitempty"`
+	Secret      string `json:"secret,omitempty"`
+
+	// match and secret are always kept for formats that redact them
+	// themselves
+	match, secret string
+}
+
+// Options configures how a report is built
+type Options struct {
+	// Placeholder keys the fingerprints, so they cannot be used to confirm
+	// a guessed secret without the user's key
+	Placeholder sanitizer.Placeholder
+	// ShowSecrets includes the raw match and secret in every finding
+	ShowSecrets bool
+	// Version is the tool version recorded in the report
+	Version string
+}
+
+// New builds the report of the findings of a history file
+func New(file string, content string, format history.Format, findings []scanner.Finding, opts Options) Report {
+	r := Report{
+		SchemaVersion: SchemaVersion,
+		Tool:          Tool{Name: ToolName, Version: opts.Version},
+		File:          file,
+		Format:        format,
+		GeneratedAt:   time.Now().UTC(),
+		Summary:       Summary{BySeverity: make(map[string]int)},
+		Findings:      make([]Finding, 0, len(findings)),
+		Rules:         scanner.Rules(),
+	}
+
+	lines := strings.Split(content, "\n")
+	entries := history.ParseEntries(content, format)
+
+	for _, f := range findings {
+		line := ""
+		if f.Line > 0 && f.Line <= len(lines) {
+			line = lines[f.Line-1]
+		}
+		secret := sanitizer.SecretValue(f.Match)
+
+		finding := Finding{
+			RuleID:      f.Type,
+			RuleIDs:     f.RuleIDs,
+			Severity:    f.Severity.String(),
+			Confidence:  f.Confidence,
+			Validated:   f.Validated,
+			Line:        f.Line,
+			Column:      f.Start + 1,
+			EndColumn:   f.End,
+			Preview:     sanitizer.Preview(line, f),
+			Entropy:     scanner.Entropy(secret),
+			Fingerprint: Fingerprint(f.Type, secret, line, opts.Placeholder),
+			match:       f.Match,
+			secret:      secret,
+		}
+		if finding.RuleIDs == nil {
+			finding.RuleIDs = []string{f.Type}
+		}
+		if rule, ok := scanner.LookupRule(f.Type); ok {
+			finding.Description = rule.Description
+		}
+		if entry, ok := history.EntryAt(entries, f.Line); ok {
+			finding.Entry = entry.Index
+			finding.Fingerprint = Fingerprint(f.Type, secret, entry.Command, opts.Placeholder)
+			if !entry.Timestamp.IsZero() {
+				ts := entry.Timestamp
+				finding.Timestamp = &ts
+			}
+		}
+		if opts.ShowSecrets {
+			finding.Match = f.Match
+			finding.Secret = secret
+		}
+
+		r.Findings = append(r.Findings, finding)
+		r.Summary.Total++
+		r.Summary.BySeverity[finding.Severity]++
+	}
+
+	return r
+}
+
+// Fingerprint identifies a secret found by a rule in a command. It hashes
+// the rule, the keyed digest of the secret and the command with the secret
+// removed and whitespace normalized, so it survives the entry moving in
+// the file but changes when the secret or the command does.
+func Fingerprint(ruleID, secret, command string, p sanitizer.Placeholder) string {
+	p.Length = 64
+	if secret != "" {
+		command = strings.ReplaceAll(command, secret, "")
+	}
+	command = strings.Join(strings.Fields(command), " ")
+
+	sum := sha256.Sum256([]byte(ruleID + "\x00" + p.ID(secret) + "\x00" + command))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,203 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolURI      = "https://github.com/arnac-io/history-sanitizer"
+
+	// fingerprintKey names our fingerprint in partialFingerprints
+	fingerprintKey = "historySanitizer/v" + SchemaVersion
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version,omitempty"`
+	InformationURI string                `json:"informationUri"`
+	Rules          []sarifReportingDescr `json:"rules"`
+}
+
+type sarifReportingDescr struct {
+	ID                   string         `json:"id"`
+	ShortDescription     sarifMessage   `json:"shortDescription"`
+	DefaultConfiguration sarifConfig    `json:"defaultConfiguration"`
+	Properties           map[string]any `json:"properties,omitempty"`
+}
+
+type sarifConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           *int              `json:"ruleIndex,omitempty"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn"`
+	EndLine     int           `json:"endLine"`
+	EndColumn   int           `json:"endColumn"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(severity string) string {
+	switch severity {
+	case scanner.SeverityCritical.String(), scanner.SeverityHigh.String():
+		return "error"
+	case scanner.SeverityLow.String():
+		return "note"
+	}
+	return "warning"
+}
+
+// securitySeverity maps a severity to the CVSS-like score code-scanning
+// dashboards use to rank security results
+func securitySeverity(s scanner.Severity) string {
+	switch s {
+	case scanner.SeverityCritical:
+		return "9.5"
+	case scanner.SeverityHigh:
+		return "8.0"
+	case scanner.SeverityLow:
+		return "2.0"
+	}
+	return "5.5"
+}
+
+// artifactURI turns a history path into a file URI
+func artifactURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	if u.Path != "" && u.Path[0] != '/' {
+		// Windows drive letters
+		u.Path = "/" + u.Path
+	}
+	return u.String()
+}
+
+// WriteSARIF writes the report as a SARIF 2.1.0 log with one run. Every
+// rule of the active rule set is described, and every finding is a result
+// located in the history file.
+func WriteSARIF(w io.Writer, r Report) error {
+	driver := sarifDriver{
+		Name:           r.Tool.Name,
+		Version:        r.Tool.Version,
+		InformationURI: toolURI,
+		Rules:          make([]sarifReportingDescr, 0, len(r.Rules)),
+	}
+	ruleIndex := make(map[string]int, len(r.Rules))
+	for i, rule := range r.Rules {
+		ruleIndex[rule.ID] = i
+		driver.Rules = append(driver.Rules, sarifReportingDescr{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfig{Level: sarifLevel(rule.Severity.String())},
+			Properties: map[string]any{
+				"tags":              []string{"security", "secret"},
+				"security-severity": securitySeverity(rule.Severity),
+			},
+		})
+	}
+
+	uri := artifactURI(r.File)
+	results := make([]sarifResult, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		description := f.Description
+		if description == "" {
+			description = f.RuleID
+		}
+		result := sarifResult{
+			RuleID:  f.RuleID,
+			Level:   sarifLevel(f.Severity),
+			Message: sarifMessage{Text: fmt.Sprintf("%s found in history entry %d", description, f.Entry)},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: uri},
+					Region: sarifRegion{
+						StartLine:   f.Line,
+						StartColumn: f.Column,
+						EndLine:     f.Line,
+						// SARIF end columns are exclusive
+						EndColumn: f.EndColumn + 1,
+						Snippet:   &sarifMessage{Text: f.Preview},
+					},
+				},
+			}},
+			PartialFingerprints: map[string]string{fingerprintKey: f.Fingerprint},
+			Properties: map[string]any{
+				"severity":   f.Severity,
+				"confidence": f.Confidence,
+				"validated":  f.Validated,
+				"entry":      f.Entry,
+				"rules":      f.RuleIDs,
+			},
+		}
+		if i, ok := ruleIndex[f.RuleID]; ok {
+			result.RuleIndex = &i
+		}
+		if f.Timestamp != nil {
+			result.Properties["timestamp"] = f.Timestamp
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
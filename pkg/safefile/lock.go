@@ -0,0 +1,96 @@
+package safefile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// lockTimeout is how long Lock waits for other writers
+var lockTimeout = 10 * time.Second
+
+const (
+	// staleLockAge is the age after which zsh considers a leftover lock
+	// file abandoned
+	staleLockAge = 10 * time.Second
+
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// ErrLocked is returned when another process holds the lock for longer
+// than the lock timeout
+var ErrLocked = errors.New("file is locked by another process")
+
+// Lock takes the locks shells use to serialize writes to a history file:
+// the <path>.LOCK file zsh creates before writing, and an fcntl write lock
+// on the file itself as taken by zsh with HIST_FCNTL_LOCK. The returned
+// function releases both.
+//
+// fcntl locks belong to the process and are dropped as soon as it closes
+// any descriptor of the file, so while the lock is held the file must only
+// be read through the returned one, never opened again by path.
+func Lock(path string) (*os.File, func() error, error) {
+	deadline := time.Now().Add(lockTimeout)
+
+	lockPath := path + ".LOCK"
+	if err := createLockFile(lockPath, deadline); err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		os.Remove(lockPath)
+		return nil, nil, err
+	}
+	if err := lockFile(f, deadline); err != nil {
+		f.Close()
+		os.Remove(lockPath)
+		return nil, nil, err
+	}
+
+	return f, func() error {
+		// Closing the file releases the fcntl lock
+		err := f.Close()
+		if rmErr := os.Remove(lockPath); rmErr != nil && err == nil {
+			err = rmErr
+		}
+		return err
+	}, nil
+}
+
+// createLockFile exclusively creates lockPath, waiting for the current
+// holder until the deadline and taking over locks left behind by crashed
+// processes
+func createLockFile(lockPath string, deadline time.Time) error {
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, err = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			return err
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		if StaleLock(lockPath) {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s", ErrLocked, lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// StaleLock reports whether the lock file at lockPath exists and is old
+// enough to have been abandoned by a crashed writer
+func StaleLock(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) > staleLockAge
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package safefile
+
+import (
+	"os"
+	"time"
+)
+
+// lockFile is a no-op where fcntl locks are not available; the lock file
+// still serializes writers
+func lockFile(f *os.File, deadline time.Time) error {
+	return nil
+}
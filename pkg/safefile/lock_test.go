@@ -0,0 +1,60 @@
+package safefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock_Exclusive(t *testing.T) {
+	defer func(d time.Duration) { lockTimeout = d }(lockTimeout)
+	lockTimeout = 200 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), ".zsh_history")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path + ".LOCK"); err != nil {
+		t.Errorf("expected zsh lock file: %v", err)
+	}
+
+	if _, _, err := Lock(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked while held, got %v", err)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, unlock, err = Lock(path)
+	if err != nil {
+		t.Fatalf("expected lock after release, got %v", err)
+	}
+	unlock()
+}
+
+func TestLock_StaleLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zsh_history")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".LOCK", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(path+".LOCK", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	_, unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("expected stale lock to be taken over, got %v", err)
+	}
+	unlock()
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package safefile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile takes an fcntl write lock on the whole file, the same lock zsh
+// takes with HIST_FCNTL_LOCK, waiting until the deadline
+func lockFile(f *os.File, deadline time.Time) error {
+	lk := unix.Flock_t{Type: unix.F_WRLCK, Whence: 0, Start: 0, Len: 0}
+	for {
+		err := unix.FcntlFlock(f.Fd(), unix.F_SETLK, &lk)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, unix.EAGAIN) && !errors.Is(err, unix.EACCES) {
+			return fmt.Errorf("failed to lock %s: %w", f.Name(), err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s", ErrLocked, f.Name())
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
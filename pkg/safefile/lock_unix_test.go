@@ -0,0 +1,66 @@
+//go:build unix
+
+package safefile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// lockHelperEnv tells the test binary to act as another process trying to
+// take the fcntl lock on the file it names
+const lockHelperEnv = "SAFEFILE_LOCK_HELPER"
+
+func TestLock_HeldWhileReading(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zsh_history")
+	if err := os.WriteFile(path, []byte("ls\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer unlock()
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(f); err != nil || string(data) != "ls\n" {
+		t.Fatalf("expected to read the file through the lock, got %q, %v", data, err)
+	}
+
+	// fcntl locks only conflict between processes, so ask another one
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLockHelperProcess$")
+	cmd.Env = append(os.Environ(), lockHelperEnv+"="+path)
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("expected another process to find the file locked, got %v: %s", err, out)
+	}
+}
+
+// TestLockHelperProcess is run by TestLock_HeldWhileReading in a separate
+// process. It exits with 3 when the file is locked.
+func TestLockHelperProcess(t *testing.T) {
+	path := os.Getenv(lockHelperEnv)
+	if path == "" {
+		t.Skip("only run as a helper process")
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := lockFile(f, time.Now()); errors.Is(err, ErrLocked) {
+		os.Exit(3)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package safefile
+
+import "os"
+
+// copyMetadata is a no-op where ownership and extended attributes are not
+// available
+func copyMetadata(path string, tmp *os.File, info os.FileInfo) error {
+	return nil
+}
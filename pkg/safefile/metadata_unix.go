@@ -0,0 +1,22 @@
+//go:build unix
+
+package safefile
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// copyMetadata gives tmp the ownership and extended attributes of the file
+// at path it is about to replace
+func copyMetadata(path string, tmp *os.File, info os.FileInfo) error {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		// Only root can give a file away; a user sanitizing their own
+		// history already owns the new file
+		if err := tmp.Chown(int(st.Uid), int(st.Gid)); err != nil && !errors.Is(err, os.ErrPermission) {
+			return err
+		}
+	}
+	return copyXattrs(path, tmp.Name())
+}
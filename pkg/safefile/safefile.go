@@ -0,0 +1,87 @@
+// Package safefile rewrites files that other processes may be using, such
+// as a shell history that open shells keep appending to
+package safefile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile atomically replaces the contents of path with data. The data is
+// written to a temporary file in the same directory, synced and renamed
+// over path, so readers see either the old or the new contents and never a
+// truncated file. If path already exists its permissions, ownership and
+// extended attributes are carried over; otherwise the file is created
+// with perm.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	// Replace the target of a symlinked history, not the link itself
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	info, err := os.Stat(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if info != nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Removing the temporary file fails harmlessly once it has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if info != nil {
+		if err := copyMetadata(path, tmp, info); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to preserve attributes of %s: %w", path, err)
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir flushes a directory so a rename in it survives a crash. Not
+// every platform supports syncing directories, so this is best effort.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// Appended compares the contents a file had when it was read with its
+// current contents. If the file only grew, it returns the bytes appended
+// since and true. If it was rewritten in any other way, e.g. truncated by a
+// shell trimming its history, it returns false.
+func Appended(before, current []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(current, before) {
+		return nil, false
+	}
+	return current[len(before):], true
+}
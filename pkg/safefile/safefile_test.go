@@ -0,0 +1,97 @@
+package safefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile_PreservesMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zsh_history")
+	if err := os.WriteFile(path, []byte("old\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	// os.WriteFile is subject to the umask
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, []byte("new\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != "new\n" {
+		t.Errorf("got %q", got)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected mode 0640 to be preserved, got %o", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected temporary file to be cleaned up, got %d entries", len(entries))
+	}
+}
+
+func TestWriteFile_NewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.sanitized")
+
+	if err := WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestWriteFile_FollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "history")
+	link := filepath.Join(dir, "link")
+	if err := os.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if err := WriteFile(link, []byte("new"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("symlink was replaced by a regular file")
+	}
+	if got, _ := os.ReadFile(target); string(got) != "new" {
+		t.Errorf("got %q in link target", got)
+	}
+}
+
+func TestAppended(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		current  string
+		wantTail string
+		wantOK   bool
+	}{
+		{"unchanged", "a\nb\n", "a\nb\n", "", true},
+		{"appended", "a\nb\n", "a\nb\nc\n", "c\n", true},
+		{"truncated", "a\nb\n", "b\n", "", false},
+		{"rewritten", "a\nb\n", "a\nx\nc\n", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tail, ok := Appended([]byte(tt.before), []byte(tt.current))
+			if ok != tt.wantOK || string(tail) != tt.wantTail {
+				t.Errorf("got (%q, %v), want (%q, %v)", tail, ok, tt.wantTail, tt.wantOK)
+			}
+		})
+	}
+}
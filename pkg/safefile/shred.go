@@ -0,0 +1,55 @@
+package safefile
+
+import (
+	"fmt"
+	"os"
+)
+
+// shredChunk is the size of the zero buffer used to overwrite files
+const shredChunk = 64 * 1024
+
+// Shred overwrites a file with zeros, syncs it and removes it. This is best
+// effort: copy-on-write and journaling filesystems, snapshots and SSD wear
+// leveling can keep the old blocks around. Symlinks are removed without
+// touching their target.
+func Shred(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	if info.Mode().IsRegular() && info.Size() > 0 {
+		if err := overwrite(path, info.Size()); err != nil {
+			return fmt.Errorf("failed to overwrite %s: %w", path, err)
+		}
+	}
+	return os.Remove(path)
+}
+
+// overwrite replaces the first size bytes of the file with zeros
+func overwrite(path string, size int64) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	zeros := make([]byte, shredChunk)
+	for written := int64(0); written < size; {
+		n := int64(len(zeros))
+		if size-written < n {
+			n = size - written
+		}
+		if _, err := f.Write(zeros[:n]); err != nil {
+			f.Close()
+			return err
+		}
+		written += n
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
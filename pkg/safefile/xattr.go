@@ -0,0 +1,54 @@
+//go:build linux || darwin
+
+package safefile
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyXattrs copies the extended attributes of src to dst, such as SELinux
+// labels or macOS quarantine flags. Filesystems without extended attribute
+// support are skipped.
+func copyXattrs(src, dst string) error {
+	size, err := unix.Listxattr(src, nil)
+	if err != nil || size == 0 {
+		return ignoreUnsupported(err)
+	}
+	buf := make([]byte, size)
+	if size, err = unix.Listxattr(src, buf); err != nil {
+		return ignoreUnsupported(err)
+	}
+
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		attr := string(name)
+		n, err := unix.Getxattr(src, attr, nil)
+		if err != nil {
+			return ignoreUnsupported(err)
+		}
+		value := make([]byte, n)
+		if n, err = unix.Getxattr(src, attr, value); err != nil {
+			return ignoreUnsupported(err)
+		}
+		// Attributes in protected namespaces need privileges the user may
+		// not have; losing them is preferable to not sanitizing
+		if err := unix.Setxattr(dst, attr, value[:n], 0); err != nil && !errors.Is(err, unix.EPERM) {
+			return ignoreUnsupported(err)
+		}
+	}
+	return nil
+}
+
+// ignoreUnsupported drops errors from filesystems without extended
+// attributes
+func ignoreUnsupported(err error) error {
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return nil
+	}
+	return err
+}
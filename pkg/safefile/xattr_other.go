@@ -0,0 +1,8 @@
+//go:build unix && !linux && !darwin
+
+package safefile
+
+// copyXattrs is a no-op on platforms without extended attribute support
+func copyXattrs(src, dst string) error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package sanitizer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultPlaceholderLength is the number of hex characters of the
+	// secret's digest included in a placeholder
+	DefaultPlaceholderLength = 8
+	minPlaceholderLength     = 4
+	maxPlaceholderLength     = 2 * sha256.Size
+)
+
+// Placeholder derives the identifiers embedded in redacted output. With a
+// Key, the identifier is an HMAC-SHA256 of the secret, so only the key
+// holder can check a candidate secret against a shared sanitized history.
+// Without a Key it falls back to a bare SHA-256, which anyone can check.
+type Placeholder struct {
+	Key []byte
+	// Length is the number of hex characters kept (DefaultPlaceholderLength
+	// if zero)
+	Length int
+}
+
+// digest returns the full keyed (or unkeyed) digest of a secret
+func (p Placeholder) digest(secret string) []byte {
+	if len(p.Key) == 0 {
+		sum := sha256.Sum256([]byte(secret))
+		return sum[:]
+	}
+	mac := hmac.New(sha256.New, p.Key)
+	mac.Write([]byte(secret))
+	return mac.Sum(nil)
+}
+
+// length returns the identifier length clamped to the supported range
+func (p Placeholder) length() int {
+	switch {
+	case p.Length == 0:
+		return DefaultPlaceholderLength
+	case p.Length < minPlaceholderLength:
+		return minPlaceholderLength
+	case p.Length > maxPlaceholderLength:
+		return maxPlaceholderLength
+	}
+	return p.Length
+}
+
+// ID returns the identifier of a secret: the first Length hex characters of
+// its digest
+func (p Placeholder) ID(secret string) string {
+	return hex.EncodeToString(p.digest(secret))[:p.length()]
+}
+
+// Format returns the placeholder for a secret, e.g. [REDACTED_TOKEN_1a2b3c4d]
+func (p Placeholder) Format(secret, secretType string) string {
+	return formatPlaceholder(p.ID(secret), secretType)
+}
+
+// placeholderID extracts the identifier from a placeholder
+var placeholderID = regexp.MustCompile(`^\[REDACTED(?:_[A-Z]+)?_([0-9a-f]+)\]$`)
+
+// Verify reports whether secret is the value that was redacted into
+// placeholder. The identifier length is taken from the placeholder itself.
+func (p Placeholder) Verify(placeholder, secret string) bool {
+	m := placeholderID.FindStringSubmatch(strings.TrimSpace(placeholder))
+	if m == nil || len(m[1]) < minPlaceholderLength || len(m[1]) > maxPlaceholderLength {
+		return false
+	}
+	want := hex.EncodeToString(p.digest(secret))[:len(m[1])]
+	return hmac.Equal([]byte(want), []byte(m[1]))
+}
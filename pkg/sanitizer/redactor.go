@@ -0,0 +1,193 @@
+package sanitizer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/arnac-io/history-sanitizer/pkg/history"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+)
+
+// Redactor produces the replacement text for a detected secret
+type Redactor interface {
+	Redact(secret string, finding scanner.Finding) string
+}
+
+// PlaceholderRedactor replaces secrets with a typed, hash-based placeholder
+// such as [REDACTED_TOKEN_1a2b3c4d]
+type PlaceholderRedactor struct {
+	Placeholder
+}
+
+// Redact implements Redactor
+func (r PlaceholderRedactor) Redact(secret string, finding scanner.Finding) string {
+	return r.Format(secret, finding.Type)
+}
+
+// MaskRedactor replaces secrets with a fixed mask that reveals nothing,
+// not even the length of the secret
+type MaskRedactor struct{}
+
+// Redact implements Redactor
+func (MaskRedactor) Redact(secret string, finding scanner.Finding) string {
+	return "****"
+}
+
+// PartialMaskRedactor keeps the first Prefix and last Suffix characters of
+// a secret and masks the rest. Secrets too short to hide anything that way
+// fall back to a placeholder.
+type PartialMaskRedactor struct {
+	Prefix int
+	Suffix int
+	Placeholder
+}
+
+// Redact implements Redactor
+func (r PartialMaskRedactor) Redact(secret string, finding scanner.Finding) string {
+	if len(secret) <= 2*(r.Prefix+r.Suffix) {
+		return r.Format(secret, finding.Type)
+	}
+	return fmt.Sprintf("%s***%s", secret[:r.Prefix], secret[len(secret)-r.Suffix:])
+}
+
+// knownPrefixes are provider prefixes kept verbatim by FakeRedactor so the
+// fake value is recognizable as the same kind of secret
+var knownPrefixes = []string{
+	"github_pat_", "sk_live_", "sk_test_", "pk_live_", "pk_test_", "sq0atp-",
+	"ghp_", "gho_", "ghu_", "ghs_", "ghr_", "npm_", "ops_", "xoxa-", "xoxb-",
+	"xoxp-", "xoxr-", "xoxs-", "pypi-", "AKIA", "ASIA", "AIza", "SG.", "eyJ",
+}
+
+// FakeRedactor replaces a secret with a fake value of the same shape:
+// provider prefix, URL scheme, length, character classes and punctuation are kept,
+// letters and digits are substituted. The substitution is derived from the
+// placeholder digest of the secret so the same secret always gets the same
+// fake. Fakes end with a tag (see scanner.TagFake) so they are not detected
+// again; secrets too short to hold one fall back to a placeholder.
+type FakeRedactor struct {
+	Placeholder
+}
+
+// Redact implements Redactor
+func (r FakeRedactor) Redact(secret string, finding scanner.Finding) string {
+	context, value := splitContext(secret)
+
+	// Keep URL schemes so connection strings still look like URLs
+	if i := strings.Index(value, "://"); i > 0 && isIdentifier(value[:i]) {
+		context += value[:i+3]
+		value = value[i+3:]
+	}
+
+	prefix := ""
+	for _, p := range knownPrefixes {
+		if strings.HasPrefix(value, p) {
+			prefix = p
+			break
+		}
+	}
+
+	seed := r.digest(secret)
+	var b strings.Builder
+	b.WriteString(context)
+	b.WriteString(prefix)
+	substituted := 0
+	for i, c := range []byte(value[len(prefix):]) {
+		n := seed[i%len(seed)] ^ byte(i/len(seed))
+		switch {
+		case c >= 'a' && c <= 'z':
+			b.WriteByte('a' + n%26)
+		case c >= 'A' && c <= 'Z':
+			b.WriteByte('A' + n%26)
+		case c >= '0' && c <= '9':
+			b.WriteByte('0' + n%10)
+		default:
+			b.WriteByte(c)
+			continue
+		}
+		substituted++
+	}
+
+	// The tag must not overwrite the kept prefix or context
+	fake, ok := scanner.TagFake(b.String())
+	if !ok || substituted < scanner.FakeTagLength {
+		return r.Format(secret, finding.Type)
+	}
+	return fake
+}
+
+// DeleteRedactor removes the whole history entry containing the secret
+// instead of rewriting it. Redact is only used when the entry cannot be
+// removed and falls back to a placeholder.
+type DeleteRedactor struct {
+	Placeholder
+}
+
+// Redact implements Redactor
+func (r DeleteRedactor) Redact(secret string, finding scanner.Finding) string {
+	return r.Format(secret, finding.Type)
+}
+
+// contextPrefix matches the non-secret context some rules include in their
+// match: `NAME=`, `--flag `, `Authorization: Bearer ` or `password: `
+var contextPrefix = regexp.MustCompile(`^(?:[A-Za-z_][A-Za-z0-9_]*=|--?[A-Za-z][A-Za-z0-9-]*(?:\s+|=)|(?i:authorization):\s*[A-Za-z]+\s+|[A-Za-z_-]+:[ \t]*)`)
+
+// splitContext separates the non-secret context of a match from the secret
+// value. An `=` only followed by more `=` is base64 padding and a `:`
+// followed by `//` is a URL scheme, neither is context.
+func splitContext(match string) (string, string) {
+	loc := contextPrefix.FindStringIndex(match)
+	if loc == nil {
+		return "", match
+	}
+	context, value := match[:loc[1]], match[loc[1]:]
+	if strings.Trim(value, "=") == "" || strings.HasPrefix(value, "//") {
+		return "", match
+	}
+	return context, value
+}
+
+// isIdentifier reports whether s is a plausible URL scheme
+func isIdentifier(s string) bool {
+	for _, c := range s {
+		if !(c == '+' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// redactorNames lists the built-in redaction strategies
+var redactorNames = []string{"delete", "env", "fake", "mask", "partial", "placeholder"}
+
+// NewRedactor returns the built-in redaction strategy with the given name,
+// deriving placeholders and fake values with p. The shell selects the
+// variable syntax of the env strategy.
+func NewRedactor(name string, p Placeholder, shell history.Shell) (Redactor, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "placeholder":
+		return PlaceholderRedactor{Placeholder: p}, nil
+	case "mask":
+		return MaskRedactor{}, nil
+	case "partial":
+		return PartialMaskRedactor{Prefix: 2, Suffix: 2, Placeholder: p}, nil
+	case "fake":
+		return FakeRedactor{Placeholder: p}, nil
+	case "delete":
+		return DeleteRedactor{Placeholder: p}, nil
+	case "env":
+		return EnvVarRedactor{Shell: shell}, nil
+	}
+	return nil, fmt.Errorf("unknown redaction strategy %q (expected one of %s)", name, strings.Join(redactorNames, ", "))
+}
+
+// ParseRedactor returns the built-in redaction strategy with the given name,
+// using unkeyed placeholders
+func ParseRedactor(name string) (Redactor, error) {
+	return NewRedactor(name, Placeholder{}, history.ShellUnknown)
+}
+
+// RedactorNames lists the names of the built-in redaction strategies
+func RedactorNames() []string {
+	return append([]string(nil), redactorNames...)
+}
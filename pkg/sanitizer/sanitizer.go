@@ -1,15 +1,72 @@
 package sanitizer
 
 import (
-	"crypto/sha256"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/arnac-io/history-sanitizer/pkg/scanner"
 )
 
+// Options controls which findings Sanitize redacts. Findings below the
+// thresholds are meant to be reported as warnings and left in place.
+type Options struct {
+	// MinSeverity is the lowest severity that gets redacted
+	MinSeverity scanner.Severity
+	// MinConfidence is the lowest confidence (0-1) that gets redacted
+	MinConfidence float64
+	// Redactor is the default redaction strategy (PlaceholderRedactor if nil)
+	Redactor Redactor
+	// RuleRedactors overrides the strategy for specific rule IDs
+	RuleRedactors map[string]Redactor
+	// FindingRedactors overrides the strategy for individual findings
+	FindingRedactors map[FindingKey]Redactor
+	// Record, if set, is called with each replacement and the secret it
+	// replaced, e.g. to keep the original in an encrypted vault
+	Record func(replacement, secret string, finding scanner.Finding)
+}
+
+// FindingKey identifies a finding by its position in the content
+type FindingKey struct {
+	Line, Start int
+}
+
+// KeyOf returns the key of a finding in Options.FindingRedactors
+func KeyOf(finding scanner.Finding) FindingKey {
+	return FindingKey{Line: finding.Line, Start: finding.Start}
+}
+
+// ShouldRedact reports whether a finding meets the redaction thresholds
+func (o Options) ShouldRedact(finding scanner.Finding) bool {
+	return finding.Severity >= o.MinSeverity && finding.Confidence >= o.MinConfidence
+}
+
+// RedactorFor returns the redaction strategy that applies to a finding
+func (o Options) RedactorFor(finding scanner.Finding) Redactor {
+	if r, ok := o.FindingRedactors[KeyOf(finding)]; ok {
+		return r
+	}
+	if r, ok := o.RuleRedactors[finding.Type]; ok {
+		return r
+	}
+	for _, id := range finding.RuleIDs {
+		if r, ok := o.RuleRedactors[id]; ok {
+			return r
+		}
+	}
+	if o.Redactor != nil {
+		return o.Redactor
+	}
+	return PlaceholderRedactor{}
+}
+
 // Sanitize obfuscates sensitive data in the content
 func Sanitize(content string, findings []scanner.Finding) string {
+	return SanitizeWithOptions(content, findings, Options{})
+}
+
+// SanitizeWithOptions obfuscates the findings that meet the thresholds in opts
+func SanitizeWithOptions(content string, findings []scanner.Finding, opts Options) string {
 	if len(findings) == 0 {
 		return content
 	}
@@ -19,53 +76,140 @@ func Sanitize(content string, findings []scanner.Finding) string {
 	// Group findings by line for efficient processing
 	findingsByLine := make(map[int][]scanner.Finding)
 	for _, finding := range findings {
+		if !opts.ShouldRedact(finding) {
+			continue
+		}
 		findingsByLine[finding.Line] = append(findingsByLine[finding.Line], finding)
 	}
 
 	// Process each line that has findings
+	deleted := make(map[int]bool)
 	for lineNum, lineFindings := range findingsByLine {
 		if lineNum < 1 || lineNum > len(lines) {
 			continue
 		}
 
 		lineIdx := lineNum - 1
-		originalLine := lines[lineIdx]
-
-		// Sort findings by start position (descending) to replace from end to start
-		// This prevents position shifts during replacement
-		sortedFindings := make([]scanner.Finding, len(lineFindings))
-		copy(sortedFindings, lineFindings)
-
-		// Simple bubble sort (descending by Start position)
-		for i := 0; i < len(sortedFindings)-1; i++ {
-			for j := 0; j < len(sortedFindings)-i-1; j++ {
-				if sortedFindings[j].Start < sortedFindings[j+1].Start {
-					sortedFindings[j], sortedFindings[j+1] = sortedFindings[j+1], sortedFindings[j]
-				}
+		if deletesEntry(lineFindings, opts) {
+			first, last := entryBounds(lines, lineIdx)
+			for i := first; i <= last; i++ {
+				deleted[i] = true
 			}
+			continue
 		}
+		lines[lineIdx] = sanitizeLine(lines[lineIdx], lineFindings, opts)
+	}
 
-		// Replace each finding with obfuscated version
-		newLine := originalLine
-		for _, finding := range sortedFindings {
-			if finding.Start < len(newLine) && finding.End <= len(newLine) {
-				replacement := obfuscate(finding.Match, finding.Type)
-				newLine = newLine[:finding.Start] + replacement + newLine[finding.End:]
+	if len(deleted) > 0 {
+		kept := lines[:0]
+		for i, line := range lines {
+			if !deleted[i] {
+				kept = append(kept, line)
 			}
 		}
-
-		lines[lineIdx] = newLine
+		lines = kept
 	}
 
 	return strings.Join(lines, "\n")
 }
 
-// obfuscate generates a replacement for sensitive data
-func obfuscate(original, secretType string) string {
-	// Create a hash-based identifier that's consistent for the same value
-	hash := sha256.Sum256([]byte(original))
-	hashStr := fmt.Sprintf("%x", hash[:4])
+// deletesEntry reports whether any finding uses the delete strategy
+func deletesEntry(findings []scanner.Finding, opts Options) bool {
+	for _, finding := range findings {
+		if _, ok := opts.RedactorFor(finding).(DeleteRedactor); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// entryBounds returns the first and last line of the history entry that
+// contains line idx, following backslash line continuations
+func entryBounds(lines []string, idx int) (int, int) {
+	first, last := idx, idx
+	for first > 0 && strings.HasSuffix(lines[first-1], "\\") {
+		first--
+	}
+	for last < len(lines)-1 && strings.HasSuffix(lines[last], "\\") {
+		last++
+	}
+	return first, last
+}
 
+// span is a byte range of a line to be replaced
+type span struct {
+	start, end int
+	finding    scanner.Finding
+}
+
+// sanitizeLine replaces the findings of a single line. Spans are resolved
+// against the original line and overlapping spans are merged before
+// replacing, so the result never depends on stale offsets. Spans that only
+// touch stay separate, as in scanner.ScanContent, so each finding gets its
+// own replacement.
+func sanitizeLine(line string, findings []scanner.Finding, opts Options) string {
+	spans := make([]span, 0, len(findings))
+	for _, finding := range findings {
+		start, end := finding.Start, finding.End
+		if start < 0 || end > len(line) || start >= end || line[start:end] != finding.Match {
+			// Offsets don't describe this line, fall back to the match text
+			start = strings.Index(line, finding.Match)
+			if finding.Match == "" || start < 0 {
+				continue
+			}
+			end = start + len(finding.Match)
+		}
+		spans = append(spans, span{start: start, end: end, finding: finding})
+	}
+	if len(spans) == 0 {
+		return line
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	merged := []span{spans[0]}
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.start >= last.end {
+			merged = append(merged, s)
+			continue
+		}
+		if s.end > last.end {
+			last.end = s.end
+		}
+		if s.finding.Severity > last.finding.Severity {
+			last.finding = s.finding
+		}
+	}
+
+	var b strings.Builder
+	prev := 0
+	for _, s := range merged {
+		b.WriteString(line[prev:s.start])
+		match := line[s.start:s.end]
+		var replacement string
+		redactor := opts.RedactorFor(s.finding)
+		if cr, ok := redactor.(ContextRedactor); ok {
+			replacement = cr.RedactInContext(match, line[:s.start], line[s.end:], s.finding)
+		} else {
+			replacement = redactor.Redact(match, s.finding)
+		}
+		if opts.Record != nil {
+			opts.Record(replacement, match, s.finding)
+		}
+		b.WriteString(replacement)
+		prev = s.end
+	}
+	b.WriteString(line[prev:])
+
+	return b.String()
+}
+
+// formatPlaceholder builds the placeholder text for a secret type around a
+// hash-based identifier
+func formatPlaceholder(hashStr, secretType string) string {
 	// Format based on type (matching Gitleaks pattern names)
 	secretTypeLower := strings.ToLower(secretType)
 	switch {
@@ -88,9 +232,34 @@ func obfuscate(original, secretType string) string {
 
 // ObfuscatePreview returns a preview of what the obfuscation would look like
 func ObfuscatePreview(original, secretType string) string {
-	if len(original) <= 8 {
-		return obfuscate(original, secretType)
-	}
 	// Show first 2 and last 2 characters with asterisks in between
-	return fmt.Sprintf("%s***%s", original[:2], original[len(original)-2:])
+	r := PartialMaskRedactor{Prefix: 2, Suffix: 2}
+	if len(original) <= 2*(r.Prefix+r.Suffix) {
+		// Too short to show any of it. Previews end up in shared reports,
+		// so no digest of the secret either: a short one is easily guessed
+		return MaskRedactor{}.Redact(original, scanner.Finding{Type: secretType})
+	}
+	return r.Redact(original, scanner.Finding{Type: secretType})
+}
+
+// SecretValue returns the secret part of a match, without the context
+// such as `NAME=` or `--password ` that some rules include
+func SecretValue(match string) string {
+	_, value := splitContext(match)
+	return value
+}
+
+// Preview returns the line a finding was made on with its secret partially
+// masked, to show where a secret is without revealing it
+func Preview(line string, finding scanner.Finding) string {
+	start, end := finding.Start, finding.End
+	if start < 0 || end > len(line) || start >= end || line[start:end] != finding.Match {
+		start = strings.Index(line, finding.Match)
+		if finding.Match == "" || start < 0 {
+			return line
+		}
+		end = start + len(finding.Match)
+	}
+	context, value := splitContext(line[start:end])
+	return line[:start] + context + ObfuscatePreview(value, finding.Type) + line[end:]
 }
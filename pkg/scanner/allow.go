@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// allowMarker matches a trailing `# hs:allow` or `# gitleaks:allow` comment,
+// optionally limited to some rules with `=rule[,rule...]`
+var allowMarker = regexp.MustCompile(`#\s*(?:hs|gitleaks):allow(?:=([\w.,-]+))?\s*$`)
+
+// FilterAllowed drops the findings of history entries that end with an
+// allow marker comment, e.g. `curl -u test:test-key ... # hs:allow` or
+// `# hs:allow=github-pat` for a single rule. The marker applies to the whole
+// entry, including lines joined by a backslash continuation. It returns the
+// remaining findings and the number of suppressed ones.
+func FilterAllowed(content string, findings []Finding) ([]Finding, int) {
+	if !strings.Contains(content, ":allow") {
+		return findings, 0
+	}
+
+	lines := strings.Split(content, "\n")
+	kept := make([]Finding, 0, len(findings))
+	for _, finding := range findings {
+		if allowed(lines, finding) {
+			continue
+		}
+		kept = append(kept, finding)
+	}
+	return kept, len(findings) - len(kept)
+}
+
+// allowed reports whether the entry of a finding carries a marker covering
+// its rule
+func allowed(lines []string, finding Finding) bool {
+	idx := finding.Line - 1
+	if idx < 0 || idx >= len(lines) {
+		return false
+	}
+	// The marker ends the entry, on its last continuation line
+	for idx < len(lines)-1 && strings.HasSuffix(lines[idx], "\\") {
+		idx++
+	}
+
+	m := allowMarker.FindStringSubmatch(lines[idx])
+	if m == nil {
+		return false
+	}
+	if m[1] == "" {
+		return true
+	}
+	for _, rule := range strings.Split(m[1], ",") {
+		if rule == finding.Type {
+			return true
+		}
+		for _, id := range finding.RuleIDs {
+			if rule == id {
+				return true
+			}
+		}
+	}
+	return false
+}
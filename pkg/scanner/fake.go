@@ -0,0 +1,86 @@
+package scanner
+
+import "crypto/sha256"
+
+const (
+	// fakeWindow is the number of alphanumeric characters a fake tag is
+	// derived from
+	fakeWindow = 8
+	// FakeTagLength is the number of alphanumeric characters, at the end
+	// of a fake value, that hold its tag
+	FakeTagLength = 6
+)
+
+// fakeSalt keeps fake tags apart from any other use of the digest
+const fakeSalt = "history-sanitizer/fake\x00"
+
+// TagFake marks a fake value generated in place of a secret so that it is
+// not reported again: its last FakeTagLength alphanumeric characters are
+// replaced with a digest of the fakeWindow alphanumeric characters before
+// them, each keeping its character class. It reports false when fake has
+// too few alphanumeric characters to hold a tag.
+func TagFake(fake string) (string, bool) {
+	b := []byte(fake)
+	positions := alphanumericPositions(b)
+	if len(positions) < fakeWindow+FakeTagLength {
+		return fake, false
+	}
+	tag := positions[len(positions)-FakeTagLength:]
+	for i, c := range fakeTag(b, positions) {
+		b[tag[i]] = c
+	}
+	return string(b), true
+}
+
+// IsFake reports whether match ends with a fake value tagged by TagFake. A
+// real secret passes by chance at most once in a million.
+func IsFake(match string) bool {
+	b := []byte(match)
+	positions := alphanumericPositions(b)
+	if len(positions) < fakeWindow+FakeTagLength {
+		return false
+	}
+	tag := positions[len(positions)-FakeTagLength:]
+	for i, c := range fakeTag(b, positions) {
+		if b[tag[i]] != c {
+			return false
+		}
+	}
+	return true
+}
+
+// fakeTag returns the tag characters for the last FakeTagLength of the
+// alphanumeric positions of b, in the class of the characters there
+func fakeTag(b []byte, positions []int) []byte {
+	n := len(positions)
+	window := make([]byte, 0, fakeWindow)
+	for _, p := range positions[n-FakeTagLength-fakeWindow : n-FakeTagLength] {
+		window = append(window, b[p])
+	}
+	sum := sha256.Sum256(append([]byte(fakeSalt), window...))
+
+	tag := make([]byte, FakeTagLength)
+	for i, p := range positions[n-FakeTagLength:] {
+		switch c := b[p]; {
+		case c >= 'a' && c <= 'z':
+			tag[i] = 'a' + sum[i]%26
+		case c >= 'A' && c <= 'Z':
+			tag[i] = 'A' + sum[i]%26
+		default:
+			tag[i] = '0' + sum[i]%10
+		}
+	}
+	return tag
+}
+
+// alphanumericPositions returns the indexes of the ASCII letters and digits
+// of b
+func alphanumericPositions(b []byte) []int {
+	var positions []int
+	for i, c := range b {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import "sort"
+
+// moreSpecific reports whether finding a should name a merged finding
+// rather than b: validated matches first, then higher severity, then the
+// narrower match (e.g. a JWT over the Authorization header around it)
+func moreSpecific(a, b Finding) bool {
+	if a.Validated != b.Validated {
+		return a.Validated
+	}
+	if a.Severity != b.Severity {
+		return a.Severity > b.Severity
+	}
+	return a.End-a.Start < b.End-b.Start
+}
+
+// resolveOverlaps merges findings of a single line whose spans overlap.
+// The merged finding covers the union of the spans so no part of any match
+// is left behind, is named after the most specific rule, and lists every
+// rule that matched in RuleIDs. Spans that only touch stay separate
+// findings, the sanitizer replaces them separately too.
+func resolveOverlaps(line string, findings []Finding) []Finding {
+	if len(findings) < 2 {
+		for i := range findings {
+			if findings[i].RuleIDs == nil {
+				findings[i].RuleIDs = []string{findings[i].Type}
+			}
+		}
+		return findings
+	}
+
+	sorted := make([]Finding, len(findings))
+	copy(sorted, findings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	var merged []Finding
+	var group []Finding
+	groupEnd := -1
+	flush := func() {
+		if len(group) > 0 {
+			merged = append(merged, mergeGroup(line, group))
+		}
+		group = group[:0]
+	}
+
+	for _, f := range sorted {
+		if len(group) > 0 && f.Start >= groupEnd {
+			flush()
+		}
+		group = append(group, f)
+		if len(group) == 1 || f.End > groupEnd {
+			groupEnd = f.End
+		}
+	}
+	flush()
+
+	return merged
+}
+
+// mergeGroup combines overlapping findings into one
+func mergeGroup(line string, group []Finding) Finding {
+	best := group[0]
+	start, end := group[0].Start, group[0].End
+	var ruleIDs []string
+	seen := make(map[string]bool)
+
+	for _, f := range group {
+		if moreSpecific(f, best) {
+			best = f
+		}
+		if f.Start < start {
+			start = f.Start
+		}
+		if f.End > end {
+			end = f.End
+		}
+	}
+
+	result := best
+	result.Start = start
+	result.End = end
+	result.Match = line[start:end]
+
+	// The chosen rule comes first, the others in order of appearance
+	for _, f := range append([]Finding{best}, group...) {
+		ids := f.RuleIDs
+		if ids == nil {
+			ids = []string{f.Type}
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				ruleIDs = append(ruleIDs, id)
+			}
+		}
+		if f.Severity > result.Severity {
+			result.Severity = f.Severity
+		}
+		if f.Confidence > result.Confidence {
+			result.Confidence = f.Confidence
+		}
+		result.Validated = result.Validated || f.Validated
+	}
+	result.RuleIDs = ruleIDs
+
+	return result
+}
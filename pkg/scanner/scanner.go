@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/pelletier/go-toml/v2"
 )
@@ -16,6 +18,17 @@ type Finding struct {
 	Line  int
 	Start int
 	End   int
+	// Validated is true when the rule has a validator and the match passed
+	// it (checksum or structural check). Shape-only matches are false.
+	Validated bool
+	// Severity is inherited from the rule that produced the finding
+	Severity Severity
+	// Confidence estimates how likely the match is a real secret (0-1),
+	// based on validation, entropy and the surrounding command
+	Confidence float64
+	// RuleIDs lists every rule that matched this span, Type first, when
+	// overlapping matches from different rules were merged
+	RuleIDs []string
 }
 
 //go:embed gitleaks.toml
@@ -26,9 +39,15 @@ var patternsConfig string
 
 // Pattern represents a secret detection pattern
 type pattern struct {
-	name  string
-	regex *regexp.Regexp
-	desc  string
+	name        string
+	regex       *lazyRegexp
+	desc        string
+	severity    Severity
+	validate    validator
+	dropInvalid bool
+	// keywords holds lowercase strings one of which every match contains;
+	// lines without any are skipped. Empty means every line is scanned.
+	keywords []string
 }
 
 // PatternConfig represents the TOML configuration structure
@@ -43,91 +62,214 @@ type PatternEntry struct {
 	Name        string `toml:"name"`
 	Regex       string `toml:"regex"`
 	Description string `toml:"description"`
+	// Severity is one of critical, high, medium or low
+	Severity string `toml:"severity"`
+	// Validator names an entry of validators to run on each match
+	Validator string `toml:"validator"`
+	// DropInvalid discards matches failing the validator instead of
+	// reporting them as unvalidated
+	DropInvalid bool `toml:"drop_invalid"`
+	// Keywords are lowercase strings one of which every match contains,
+	// case-insensitively, so the regex only runs on lines holding one
+	Keywords []string `toml:"keywords"`
 }
 
-// detectionPatterns is loaded from patterns.toml at init time
-var detectionPatterns []pattern
+// lazyRegexp compiles a regular expression the first time it is used, so
+// a short-lived process only pays for the rules its input may match
+type lazyRegexp struct {
+	source string
+	once   sync.Once
+	re     *regexp.Regexp
+}
+
+func newLazyRegexp(source string) *lazyRegexp {
+	return &lazyRegexp{source: source}
+}
 
-func init() {
+// get returns the compiled expression, or nil if it does not compile
+func (l *lazyRegexp) get(rule string) *regexp.Regexp {
+	l.once.Do(func() {
+		re, err := regexp.Compile(l.source)
+		if err != nil {
+			fmt.Printf("Warning: failed to compile pattern %s: %v\n", rule, err)
+			return
+		}
+		l.re = re
+	})
+	return l.re
+}
+
+// detectionPatterns returns the patterns of patterns.toml, loaded on first
+// use. Their regexes are only compiled when a line may match them.
+var detectionPatterns = sync.OnceValue(loadPatterns)
+
+func loadPatterns() []pattern {
 	// Load patterns from embedded TOML config
 	var config PatternConfig
 	err := toml.Unmarshal([]byte(patternsConfig), &config)
 	if err != nil {
 		// Fallback to empty patterns - will cause errors but won't crash
 		fmt.Printf("Warning: failed to load patterns config: %v\n", err)
-		return
+		return nil
 	}
 
-	// Compile regex patterns
-	detectionPatterns = make([]pattern, 0, len(config.Patterns))
+	patterns := make([]pattern, 0, len(config.Patterns))
 	for _, p := range config.Patterns {
-		compiled, err := regexp.Compile(p.Regex)
-		if err != nil {
-			fmt.Printf("Warning: failed to compile pattern %s: %v\n", p.Name, err)
-			continue
+		severity := defaultSeverity
+		if p.Severity != "" {
+			severity, err = ParseSeverity(p.Severity)
+			if err != nil {
+				fmt.Printf("Warning: pattern %s: %v\n", p.Name, err)
+				severity = defaultSeverity
+			}
 		}
-		detectionPatterns = append(detectionPatterns, pattern{
-			name:  p.Name,
-			regex: compiled,
-			desc:  p.Description,
+		var validate validator
+		if p.Validator != "" {
+			validate = validators[p.Validator]
+			if validate == nil {
+				fmt.Printf("Warning: unknown validator %s for pattern %s\n", p.Validator, p.Name)
+			}
+		}
+		patterns = append(patterns, pattern{
+			name:        p.Name,
+			regex:       newLazyRegexp(p.Regex),
+			desc:        p.Description,
+			severity:    severity,
+			validate:    validate,
+			dropInvalid: p.DropInvalid,
+			keywords:    p.Keywords,
 		})
 	}
+	return patterns
 }
 
 // Fallback patterns if TOML loading fails (minimal set)
 var fallbackPatterns = []pattern{
 	{
-		name:  "aws-access-token",
-		regex: regexp.MustCompile(`(AKIA|ASIA)[A-Z0-9]{16}`),
-		desc:  "AWS Access Token",
+		name:     "aws-access-token",
+		regex:    newLazyRegexp(`(AKIA|ASIA)[A-Z0-9]{16}`),
+		desc:     "AWS Access Token",
+		severity: SeverityCritical,
 	},
 	{
-		name:  "github-pat",
-		regex: regexp.MustCompile(`ghp_[0-9a-zA-Z]{36}`),
-		desc:  "GitHub Personal Access Token",
+		name:     "github-pat",
+		regex:    newLazyRegexp(`ghp_[0-9a-zA-Z]{36}`),
+		desc:     "GitHub Personal Access Token",
+		severity: SeverityCritical,
+		validate: validateGitHubChecksum,
 	},
 	{
-		name:  "jwt",
-		regex: regexp.MustCompile(`eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
-		desc:  "JSON Web Token",
+		name:     "jwt",
+		regex:    newLazyRegexp(`eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+		desc:     "JSON Web Token",
+		severity: SeverityHigh,
 	},
 }
 
+// CompileRules compiles every rule now rather than on first use, so a
+// long-running process answers its first scan as fast as the next ones
+func CompileRules() {
+	for _, p := range detectionPatterns() {
+		p.regex.get(p.name)
+	}
+}
+
+// mayMatch reports whether a line holds one of the keywords of the
+// pattern, given the lowercased line. Without filter every line may match.
+func (p *pattern) mayMatch(lower string, filter bool) bool {
+	if len(p.keywords) == 0 || !filter {
+		return true
+	}
+	for _, k := range p.keywords {
+		if strings.Contains(lower, k) {
+			return true
+		}
+	}
+	return false
+}
+
 // ScanContent scans the content for sensitive information
 // Uses detection patterns loaded from patterns.toml (sourced from Gitleaks)
 func ScanContent(content string) ([]Finding, error) {
 	// Use fallback if patterns failed to load
-	patterns := detectionPatterns
+	patterns := detectionPatterns()
 	if len(patterns) == 0 {
 		patterns = fallbackPatterns
 	}
+	return scan(content, patterns, true), nil
+}
 
+// scan runs the patterns on each line of content, skipping the patterns
+// whose keywords are missing from the line when prefilter is set
+func scan(content string, patterns []pattern, prefilter bool) []Finding {
 	var results []Finding
 	lines := strings.Split(content, "\n")
 
 	// Scan each line with all patterns
 	for lineNum, line := range lines {
+		// Case-insensitive regexes also fold some non-ASCII letters into
+		// ASCII ones (ſ matches s), which lowercasing does not, so only
+		// ASCII lines are prefiltered
+		filter := prefilter && isASCII(line)
+		lower := ""
+		if filter {
+			lower = strings.ToLower(line)
+		}
+
+		var lineFindings []Finding
 		for _, p := range patterns {
-			matches := p.regex.FindAllStringIndex(line, -1)
+			if !p.mayMatch(lower, filter) {
+				continue
+			}
+			re := p.regex.get(p.name)
+			if re == nil {
+				continue
+			}
+			matches := re.FindAllStringIndex(line, -1)
 			if matches != nil {
 				for _, match := range matches {
 					if len(match) >= 2 {
 						matchedText := line[match[0]:match[1]]
+						validated := false
+						if p.validate != nil {
+							validated = p.validate(matchedText)
+							if !validated && p.dropInvalid {
+								continue
+							}
+						}
 						finding := Finding{
-							Type:  p.name,
-							Match: matchedText,
-							Line:  lineNum + 1,
-							Start: match[0],
-							End:   match[1],
+							Type:       p.name,
+							Match:      matchedText,
+							Line:       lineNum + 1,
+							Start:      match[0],
+							End:        match[1],
+							Validated:  validated,
+							Severity:   p.severity,
+							Confidence: computeConfidence(p, line, matchedText, validated),
 						}
-						results = append(results, finding)
+						lineFindings = append(lineFindings, finding)
 					}
 				}
 			}
 		}
+		// Fakes written by the fake redaction strategy are not secrets
+		for _, finding := range resolveOverlaps(line, lineFindings) {
+			if !IsFake(finding.Match) {
+				results = append(results, finding)
+			}
+		}
 	}
 
-	return results, nil
+	return results
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
 }
 
 // findLineNumber finds which line contains a character at the given position
@@ -162,18 +304,44 @@ func findPositionInLine(lines []string, lineNum int, secret string) (int, int) {
 
 // GetPatternCount returns the number of patterns being scanned
 func GetPatternCount() int {
-	return len(detectionPatterns)
+	return len(detectionPatterns())
 }
 
 // GetDetectorInfo returns information about the detector rules
 func GetDetectorInfo() ([]string, error) {
 	var info []string
-	for _, p := range detectionPatterns {
+	for _, p := range detectionPatterns() {
 		info = append(info, p.name+": "+p.desc)
 	}
 	return info, nil
 }
 
+// Rule describes a detection rule of the active rule set
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+}
+
+// Rules returns the active detection rules in the order they are applied
+func Rules() []Rule {
+	rules := make([]Rule, 0, len(detectionPatterns()))
+	for _, p := range detectionPatterns() {
+		rules = append(rules, Rule{ID: p.name, Description: p.desc, Severity: p.severity})
+	}
+	return rules
+}
+
+// LookupRule returns the active rule with the given ID
+func LookupRule(id string) (Rule, bool) {
+	for _, p := range detectionPatterns() {
+		if p.name == id {
+			return Rule{ID: p.name, Description: p.desc, Severity: p.severity}, true
+		}
+	}
+	return Rule{}, false
+}
+
 // GetGitleaksConfigSize returns the size of the embedded Gitleaks config
 // This demonstrates that we have the full Gitleaks config available for reference
 func GetGitleaksConfigSize() int {
@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Severity ranks how damaging a leaked secret of a given rule would be
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// defaultSeverity is used for rules that do not declare one
+const defaultSeverity = SeverityMedium
+
+var severityNames = map[Severity]string{
+	SeverityLow:      "low",
+	SeverityMedium:   "medium",
+	SeverityHigh:     "high",
+	SeverityCritical: "critical",
+}
+
+// String returns the lowercase name of the severity
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseSeverity parses a severity name (case-insensitive)
+func ParseSeverity(name string) (Severity, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for s, n := range severityNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return SeverityUnknown, fmt.Errorf("unknown severity %q (expected critical, high, medium or low)", name)
+}
+
+// contextPattern matches assignments and flags that usually carry a
+// secret, raising confidence in a match found on the same line
+var contextPattern = regexp.MustCompile(`(?i)(export\s|token|secret|passw|api[_-]?key|auth|bearer|credential|--(user|password|key)\b)`)
+
+// Entropy returns the Shannon entropy of s in bits per character, as
+// reported by Gitleaks
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// computeConfidence estimates how likely a match is a real secret, between
+// 0 and 1. A passing validator is conclusive; otherwise the score combines
+// the randomness of the match with hints from the surrounding command.
+func computeConfidence(p pattern, line, match string, validated bool) float64 {
+	if validated {
+		return 1
+	}
+
+	// 4 bits per character is typical of random tokens
+	entropyScore := math.Min(Entropy(match)/4, 1)
+	confidence := 0.3 + 0.5*entropyScore
+	if contextPattern.MatchString(line) {
+		confidence += 0.2
+	}
+
+	// A failed validator means the shape is right but the content is not
+	if p.validate != nil {
+		confidence = math.Min(confidence, 0.3)
+	}
+
+	return math.Round(math.Min(confidence, 1)*100) / 100
+}
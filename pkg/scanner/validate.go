@@ -0,0 +1,138 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+)
+
+// validator checks a matched secret against the structural invariants or
+// checksum defined by its provider. A match that only has the right shape
+// but fails validation is most likely a placeholder or a typo.
+type validator func(secret string) bool
+
+// validators maps the `validator` names usable in patterns.toml to their
+// implementation
+var validators = map[string]validator{
+	"github-checksum":     validateGitHubChecksum,
+	"github-fine-grained": validateGitHubFineGrained,
+	"npm-checksum":        validateNpmChecksum,
+	"pypi-macaroon":       validatePyPIMacaroon,
+	"slack-token":         validateSlackToken,
+}
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// crc32Base62 encodes the CRC32 of data in base62, left padded with zeros
+// to 6 characters. This is the checksum scheme used by GitHub and npm
+// tokens.
+func crc32Base62(data string) string {
+	sum := crc32.ChecksumIEEE([]byte(data))
+	var buf [6]byte
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = base62Alphabet[sum%62]
+		sum /= 62
+	}
+	return string(buf[:])
+}
+
+// validatePrefixedChecksum validates tokens made of a prefix, a 30 character
+// random part and a 6 character base62 CRC32 of the random part
+func validatePrefixedChecksum(secret, prefix string) bool {
+	if !strings.HasPrefix(secret, prefix) {
+		return false
+	}
+	body := secret[len(prefix):]
+	if len(body) != 36 {
+		return false
+	}
+	return crc32Base62(body[:30]) == body[30:]
+}
+
+// validateGitHubChecksum validates classic GitHub tokens (ghp_, gho_, ghu_,
+// ghs_) whose last 6 characters are a checksum of the random part
+func validateGitHubChecksum(secret string) bool {
+	if len(secret) < 4 || secret[3] != '_' {
+		return false
+	}
+	switch secret[:3] {
+	case "ghp", "gho", "ghu", "ghs":
+		return validatePrefixedChecksum(secret, secret[:4])
+	}
+	return false
+}
+
+// validateGitHubFineGrained checks the layout of fine-grained personal access
+// tokens: github_pat_<22 characters>_<59 characters>. GitHub does not
+// document their checksum, so only the layout is verified.
+func validateGitHubFineGrained(secret string) bool {
+	body, ok := strings.CutPrefix(secret, "github_pat_")
+	if !ok || len(body) != 82 || body[22] != '_' {
+		return false
+	}
+	return isAlphanumeric(body[:22]) && isAlphanumeric(body[23:])
+}
+
+// validateNpmChecksum validates npm access tokens, which follow the same
+// checksum scheme as GitHub tokens
+func validateNpmChecksum(secret string) bool {
+	return validatePrefixedChecksum(secret, "npm_")
+}
+
+// validatePyPIMacaroon checks that a PyPI token wraps a well-formed
+// version 2 macaroon: a location field followed by an identifier field and
+// the end of the header section
+func validatePyPIMacaroon(secret string) bool {
+	body, ok := strings.CutPrefix(secret, "pypi-")
+	if !ok {
+		return false
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(body, "="))
+	if err != nil || len(decoded) < 2 || decoded[0] != 0x02 {
+		return false
+	}
+
+	pos := 1
+	for _, field := range []byte{0x01, 0x02} {
+		if pos >= len(decoded) || decoded[pos] != field {
+			return false
+		}
+		length, n := binary.Uvarint(decoded[pos+1:])
+		if n <= 0 || length == 0 || uint64(len(decoded)-pos-1-n) < length {
+			return false
+		}
+		pos += 1 + n + int(length)
+	}
+	return pos < len(decoded) && decoded[pos] == 0x00
+}
+
+// validateSlackToken checks the structure of Slack tokens:
+// xox?-<numeric ids>-<secret>, rejecting the all-zero ids and repeated
+// characters that documentation examples use
+func validateSlackToken(secret string) bool {
+	parts := strings.Split(secret, "-")
+	if len(parts) < 3 || !strings.HasPrefix(parts[0], "xox") {
+		return false
+	}
+	for _, id := range parts[1 : len(parts)-1] {
+		if id == "" || strings.Trim(id, "0123456789") != "" || strings.Trim(id, "0") == "" {
+			return false
+		}
+	}
+	last := parts[len(parts)-1]
+	if len(last) != 24 && len(last) != 32 {
+		return false
+	}
+	return isAlphanumeric(last) && strings.Trim(last, last[:1]) != ""
+}
+
+// isAlphanumeric reports whether s only contains ASCII letters and digits
+func isAlphanumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !strings.ContainsRune(base62Alphabet, rune(s[i])) {
+			return false
+		}
+	}
+	return s != ""
+}
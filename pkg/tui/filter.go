@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the accepted formats of after: and before:
+var dateLayouts = []string{"2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+
+// Filter narrows the listed findings. A query such as
+// `rule:github-pat after:2024-01-01 curl` combines a rule, a time range and
+// text searched in the rule and the masked command.
+type Filter struct {
+	Text string
+	Rule string
+	// From and To bound when the command was run. Findings without a
+	// timestamp are hidden when either is set.
+	From, To time.Time
+}
+
+// ParseFilter parses a filter query. Dates are taken in local time.
+func ParseFilter(query string) (Filter, error) {
+	var f Filter
+	var words []string
+	for _, field := range strings.Fields(query) {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || value == "" {
+			words = append(words, field)
+			continue
+		}
+		var err error
+		switch strings.ToLower(key) {
+		case "rule":
+			f.Rule = value
+		case "after", "from":
+			f.From, err = parseDate(value)
+		case "before", "to":
+			f.To, err = parseDate(value)
+		default:
+			words = append(words, field)
+		}
+		if err != nil {
+			return Filter{}, err
+		}
+	}
+	f.Text = strings.ToLower(strings.Join(words, " "))
+	return f, nil
+}
+
+// parseDate parses a date of a filter query
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or YYYY-MM-DDTHH:MM", value)
+}
+
+// Match reports whether an item passes the filter. line is the masked
+// command of the item.
+func (f Filter) Match(it Item, line string) bool {
+	if f.Rule != "" && !strings.EqualFold(f.Rule, it.Finding.Type) {
+		return false
+	}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		if it.Timestamp.IsZero() {
+			return false
+		}
+		if !f.From.IsZero() && it.Timestamp.Before(f.From) {
+			return false
+		}
+		if !f.To.IsZero() && !it.Timestamp.Before(f.To) {
+			return false
+		}
+	}
+	if f.Text != "" {
+		return strings.Contains(strings.ToLower(it.Finding.Type), f.Text) ||
+			strings.Contains(strings.ToLower(line), f.Text)
+	}
+	return true
+}
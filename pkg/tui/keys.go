@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Key is a key press: the name of a special key, or the typed character
+type Key string
+
+// Special keys
+const (
+	KeyUp        Key = "up"
+	KeyDown      Key = "down"
+	KeyLeft      Key = "left"
+	KeyRight     Key = "right"
+	KeyPageUp    Key = "pgup"
+	KeyPageDown  Key = "pgdown"
+	KeyHome      Key = "home"
+	KeyEnd       Key = "end"
+	KeyEnter     Key = "enter"
+	KeyEscape    Key = "esc"
+	KeyBackspace Key = "backspace"
+	KeySpace     Key = "space"
+	KeyCtrlC     Key = "ctrl+c"
+)
+
+// Printable reports whether the key is a typed character
+func (k Key) Printable() bool {
+	r, size := utf8.DecodeRuneInString(string(k))
+	return size == len(k) && r != utf8.RuneError && unicode.IsPrint(r)
+}
+
+// csiKeys maps the final part of ANSI escape sequences to keys
+var csiKeys = map[string]Key{
+	"A": KeyUp, "B": KeyDown, "C": KeyRight, "D": KeyLeft,
+	"H": KeyHome, "F": KeyEnd,
+	"1~": KeyHome, "7~": KeyHome, "4~": KeyEnd, "8~": KeyEnd,
+	"5~": KeyPageUp, "6~": KeyPageDown,
+}
+
+// ParseKeys decodes the bytes read from a terminal in raw mode
+func ParseKeys(b []byte) []Key {
+	var keys []Key
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b:
+			// ESC [ ... or ESC O ... sequences, a lone ESC otherwise
+			if len(b) > 2 && (b[1] == '[' || b[1] == 'O') {
+				end := 2
+				for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+					end++
+				}
+				if end == len(b) {
+					return keys
+				}
+				if k, ok := csiKeys[string(b[2:end+1])]; ok {
+					keys = append(keys, k)
+				}
+				b = b[end+1:]
+				continue
+			}
+			keys = append(keys, KeyEscape)
+			b = b[1:]
+		case c == '\r' || c == '\n':
+			keys = append(keys, KeyEnter)
+			b = b[1:]
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, KeyBackspace)
+			b = b[1:]
+		case c == 0x03:
+			keys = append(keys, KeyCtrlC)
+			b = b[1:]
+		case c == ' ':
+			keys = append(keys, KeySpace)
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			if unicode.IsPrint(r) {
+				keys = append(keys, Key(string(r)))
+			}
+			b = b[size:]
+		}
+	}
+	return keys
+}
@@ -0,0 +1,417 @@
+// Package tui is a full-screen terminal interface to browse the findings
+// of a history file and choose the ones to redact
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+)
+
+// Item is a finding listed in the interface
+type Item struct {
+	Finding scanner.Finding
+	// Timestamp is when the command was run, zero if the history does not
+	// record it
+	Timestamp time.Time
+	Selected  bool
+}
+
+// SanitizeFunc returns a line with the given findings of that line
+// redacted. The findings' Line is always 1.
+type SanitizeFunc func(line string, findings []scanner.Finding) string
+
+// mode is the screen being shown
+type mode int
+
+const (
+	modeList mode = iota
+	modeSearch
+	modeDiff
+	modeHelp
+)
+
+// group lists the items of one rule, in line order
+type group struct {
+	rule     string
+	severity scanner.Severity
+	items    []int
+}
+
+// row is a line of the list: a group header when item is -1
+type row struct {
+	group int
+	item  int
+}
+
+// Model is the state of the interface. Update applies a key press and View
+// renders the screen, so the behavior is independent of the terminal.
+type Model struct {
+	file     string
+	items    []Item
+	raw      []string
+	masked   []string
+	sanitize SanitizeFunc
+	groups   []group
+
+	filter    Filter
+	query     string
+	input     string
+	collapsed map[string]bool
+	rows      []row
+	cursor    int
+	offset    int
+	scroll    int
+	mode      mode
+	message   string
+
+	done, write bool
+}
+
+// New returns the model of the findings of a history file. sanitize
+// renders the diff preview.
+func New(file, content string, items []Item, sanitize SanitizeFunc) *Model {
+	m := &Model{
+		file:      file,
+		items:     items,
+		raw:       strings.Split(content, "\n"),
+		sanitize:  sanitize,
+		collapsed: make(map[string]bool),
+	}
+
+	byLine := make(map[int][]scanner.Finding)
+	byRule := make(map[string]int)
+	for i, it := range items {
+		f := it.Finding
+		byLine[f.Line] = append(byLine[f.Line], f)
+
+		g, ok := byRule[f.Type]
+		if !ok {
+			g = len(m.groups)
+			byRule[f.Type] = g
+			m.groups = append(m.groups, group{rule: f.Type})
+		}
+		m.groups[g].items = append(m.groups[g].items, i)
+		if f.Severity > m.groups[g].severity {
+			m.groups[g].severity = f.Severity
+		}
+	}
+	// Most severe rules first
+	sort.SliceStable(m.groups, func(i, j int) bool {
+		if m.groups[i].severity != m.groups[j].severity {
+			return m.groups[i].severity > m.groups[j].severity
+		}
+		return m.groups[i].rule < m.groups[j].rule
+	})
+	for _, g := range m.groups {
+		sort.SliceStable(g.items, func(i, j int) bool {
+			return items[g.items[i]].Finding.Line < items[g.items[j]].Finding.Line
+		})
+	}
+
+	// Secrets are never shown in full, even in the context pane
+	m.masked = make([]string, len(m.raw))
+	for i, line := range m.raw {
+		m.masked[i] = maskLine(line, byLine[i+1])
+	}
+
+	m.refresh()
+	return m
+}
+
+// maskLine partially masks every finding of a line, right to left so the
+// offsets of the others stay valid
+func maskLine(line string, findings []scanner.Finding) string {
+	sorted := append([]scanner.Finding(nil), findings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start > sorted[j].Start })
+	for _, f := range sorted {
+		line = sanitizer.Preview(line, f)
+	}
+	return line
+}
+
+// Done reports whether the user left the interface
+func (m *Model) Done() bool {
+	return m.done
+}
+
+// Write reports whether the user confirmed writing the selected redactions
+func (m *Model) Write() bool {
+	return m.write
+}
+
+// Selected returns the selected findings, in line order
+func (m *Model) Selected() []scanner.Finding {
+	var selected []scanner.Finding
+	for _, it := range m.items {
+		if it.Selected {
+			selected = append(selected, it.Finding)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Line < selected[j].Line
+	})
+	return selected
+}
+
+// visible reports whether an item passes the filter
+func (m *Model) visible(i int) bool {
+	it := m.items[i]
+	return m.filter.Match(it, m.line(it.Finding.Line))
+}
+
+// line returns a masked line of the history
+func (m *Model) line(n int) string {
+	if n < 1 || n > len(m.masked) {
+		return ""
+	}
+	return m.masked[n-1]
+}
+
+// refresh rebuilds the rows after the filter or folding changed
+func (m *Model) refresh() {
+	current := row{item: -1}
+	if m.cursor < len(m.rows) {
+		current = m.rows[m.cursor]
+	}
+
+	m.rows = m.rows[:0]
+	for gi, g := range m.groups {
+		var items []int
+		for _, i := range g.items {
+			if m.visible(i) {
+				items = append(items, i)
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+		m.rows = append(m.rows, row{group: gi, item: -1})
+		if m.collapsed[g.rule] {
+			continue
+		}
+		for _, i := range items {
+			m.rows = append(m.rows, row{group: gi, item: i})
+		}
+	}
+
+	// Stay on the same row when it is still listed
+	m.cursor = 0
+	for i, r := range m.rows {
+		if r == current {
+			m.cursor = i
+			break
+		}
+		if r.group == current.group && r.item == -1 {
+			m.cursor = i
+		}
+	}
+}
+
+// visibleItems returns the items of a group that pass the filter
+func (m *Model) visibleItems(g int) []int {
+	var items []int
+	for _, i := range m.groups[g].items {
+		if m.visible(i) {
+			items = append(items, i)
+		}
+	}
+	return items
+}
+
+// counts returns the number of selected and total items among items
+func (m *Model) counts(items []int) (selected, total int) {
+	for _, i := range items {
+		if m.items[i].Selected {
+			selected++
+		}
+	}
+	return selected, len(items)
+}
+
+// Update applies a key press
+func (m *Model) Update(k Key) {
+	m.message = ""
+	if k == KeyCtrlC {
+		m.done = true
+		return
+	}
+
+	switch m.mode {
+	case modeSearch:
+		m.updateSearch(k)
+	case modeDiff:
+		m.updateDiff(k)
+	case modeHelp:
+		m.mode = modeList
+	default:
+		m.updateList(k)
+	}
+}
+
+func (m *Model) updateList(k Key) {
+	switch k {
+	case KeyUp, "k":
+		m.move(-1)
+	case KeyDown, "j":
+		m.move(1)
+	case KeyPageUp:
+		m.move(-10)
+	case KeyPageDown:
+		m.move(10)
+	case KeyHome, "g":
+		m.move(-len(m.rows))
+	case KeyEnd, "G":
+		m.move(len(m.rows))
+	case KeySpace:
+		m.toggle()
+	case "a":
+		m.selectVisible(true)
+	case "n":
+		m.selectVisible(false)
+	case KeyEnter, KeyRight, KeyLeft:
+		m.fold(k)
+	case "/":
+		m.mode = modeSearch
+		m.input = m.query
+	case KeyEscape:
+		if m.query != "" {
+			m.query, m.filter = "", Filter{}
+			m.refresh()
+			m.message = "Filter cleared"
+		}
+	case "d", "w":
+		m.mode = modeDiff
+		m.scroll = 0
+	case "?":
+		m.mode = modeHelp
+	case "q":
+		m.done = true
+	}
+}
+
+func (m *Model) updateSearch(k Key) {
+	switch k {
+	case KeyEnter:
+		filter, err := ParseFilter(m.input)
+		if err != nil {
+			m.message = err.Error()
+			return
+		}
+		m.query, m.filter = m.input, filter
+		m.mode = modeList
+		m.refresh()
+		if len(m.rows) == 0 {
+			m.message = "No finding matches " + m.query
+		}
+	case KeyEscape:
+		m.mode = modeList
+	case KeyBackspace:
+		if r := []rune(m.input); len(r) > 0 {
+			m.input = string(r[:len(r)-1])
+		}
+	case KeySpace:
+		m.input += " "
+	default:
+		if k.Printable() {
+			m.input += string(k)
+		}
+	}
+}
+
+func (m *Model) updateDiff(k Key) {
+	switch k {
+	case KeyUp, "k":
+		m.scroll--
+	case KeyDown, "j":
+		m.scroll++
+	case KeyPageUp:
+		m.scroll -= 10
+	case KeyPageDown:
+		m.scroll += 10
+	case "w", "y":
+		if len(m.Selected()) == 0 {
+			m.message = "No finding selected"
+			return
+		}
+		m.write = true
+		m.done = true
+	case KeyEscape, "q", "d":
+		m.mode = modeList
+	}
+	if m.scroll < 0 {
+		m.scroll = 0
+	}
+}
+
+// move moves the cursor by delta rows
+func (m *Model) move(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// toggle selects or deselects the item under the cursor, or every visible
+// item of the group on a group header
+func (m *Model) toggle() {
+	if len(m.rows) == 0 {
+		return
+	}
+	r := m.rows[m.cursor]
+	if r.item >= 0 {
+		m.items[r.item].Selected = !m.items[r.item].Selected
+		return
+	}
+	items := m.visibleItems(r.group)
+	selected, total := m.counts(items)
+	for _, i := range items {
+		m.items[i].Selected = selected < total
+	}
+}
+
+// selectVisible selects or deselects every item passing the filter
+func (m *Model) selectVisible(selected bool) {
+	n := 0
+	for i := range m.items {
+		if m.visible(i) {
+			m.items[i].Selected = selected
+			n++
+		}
+	}
+	if selected {
+		m.message = fmt.Sprintf("Selected %d finding(s)", n)
+	} else {
+		m.message = fmt.Sprintf("Deselected %d finding(s)", n)
+	}
+}
+
+// fold expands or collapses the group under the cursor
+func (m *Model) fold(k Key) {
+	if len(m.rows) == 0 {
+		return
+	}
+	r := m.rows[m.cursor]
+	rule := m.groups[r.group].rule
+	switch {
+	case r.item >= 0 && k == KeyLeft:
+		m.collapsed[rule] = true
+		m.rows[m.cursor].item = -1
+	case r.item >= 0:
+		return
+	case k == KeyRight:
+		m.collapsed[rule] = false
+	case k == KeyLeft:
+		m.collapsed[rule] = true
+	default:
+		m.collapsed[rule] = !m.collapsed[rule]
+	}
+	m.refresh()
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Terminal control sequences
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	leaveAltScreen = "\x1b[?25h\x1b[?1049l"
+	cursorHome     = "\x1b[H"
+	clearLine      = "\x1b[K"
+)
+
+// ErrNotTerminal is returned when stdin or stdout is not a terminal
+var ErrNotTerminal = errors.New("the TUI needs an interactive terminal")
+
+// Run shows the interface on the terminal until the user quits or
+// confirms writing
+func Run(m *Model) error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return ErrNotTerminal
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(in, state)
+
+	fmt.Print(enterAltScreen)
+	defer fmt.Print(leaveAltScreen)
+
+	buf := make([]byte, 256)
+	for !m.Done() {
+		width, height, err := term.GetSize(out)
+		if err != nil {
+			width, height = 80, 24
+		}
+		// Redraw over the previous screen rather than clearing it, which
+		// flickers
+		screen := strings.ReplaceAll(m.View(width, height), "\n", clearLine+"\r\n")
+		fmt.Print(cursorHome + screen + clearLine)
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return fmt.Errorf("failed to read from terminal: %w", err)
+		}
+		for _, k := range ParseKeys(buf[:n]) {
+			m.Update(k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/arnac-io/history-sanitizer/pkg/sanitizer"
+	"github.com/arnac-io/history-sanitizer/pkg/scanner"
+)
+
+// ANSI styles
+const (
+	styleReverse = "\x1b[7m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleRed     = "\x1b[31m"
+	styleGreen   = "\x1b[32m"
+	styleYellow  = "\x1b[33m"
+	styleReset   = "\x1b[0m"
+)
+
+const listHints = "↑↓ move  space select  a/n all/none  enter fold  / filter  d diff  w write  q quit  ? help"
+
+var helpText = []string{
+	"Keys",
+	"",
+	"  ↑ ↓ j k         move",
+	"  PgUp PgDn g G   move by page, to the top or bottom",
+	"  space           select or deselect the finding, or the whole rule on a rule header",
+	"  a  n            select or deselect every finding matching the filter",
+	"  enter ← →       fold or unfold the rule",
+	"  /               filter, esc clears the filter",
+	"  d  w            preview the diff of the selected redactions, then w to write",
+	"  q  ctrl+c       quit without writing",
+	"",
+	"Filter",
+	"",
+	"  rule:<id>          findings of one rule",
+	"  after:<date>       commands run on or after a date (YYYY-MM-DD or YYYY-MM-DDTHH:MM)",
+	"  before:<date>      commands run before a date",
+	"  any other text     searched in the rule and the masked command",
+	"",
+	"Press any key to go back",
+}
+
+// View renders the screen as height lines of at most width columns
+func (m *Model) View(width, height int) string {
+	if width < 20 {
+		width = 20
+	}
+	if height < 8 {
+		height = 8
+	}
+
+	var lines []string
+	switch m.mode {
+	case modeDiff:
+		lines = m.viewDiff(width, height)
+	case modeHelp:
+		lines = m.viewHelp(width, height)
+	default:
+		lines = m.viewList(width, height)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) viewList(width, height int) []string {
+	var all []int
+	for i := range m.items {
+		if m.visible(i) {
+			all = append(all, i)
+		}
+	}
+	selected, total := m.counts(all)
+	title := fmt.Sprintf(" history-sanitizer  %s  %d/%d selected", m.file, selected, total)
+	if m.query != "" {
+		title += "  filter: " + m.query
+	}
+	lines := []string{styleReverse + fit(title, width) + styleReset}
+
+	context := 7
+	if height < 20 {
+		context = 3
+	}
+	listHeight := height - context - 3
+
+	// Keep the cursor on screen
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+listHeight {
+		m.offset = m.cursor - listHeight + 1
+	}
+
+	for i := m.offset; i < m.offset+listHeight; i++ {
+		switch {
+		case i < len(m.rows):
+			lines = append(lines, m.renderRow(i, width))
+		case i == 0:
+			lines = append(lines, fit("  No finding matches the filter", width))
+		default:
+			lines = append(lines, "")
+		}
+	}
+
+	lines = append(lines, styleDim+fit("── surrounding history "+strings.Repeat("─", width), width)+styleReset)
+	lines = append(lines, m.renderContext(width, context)...)
+
+	switch {
+	case m.mode == modeSearch:
+		lines = append(lines, fit("/"+m.input+"_", width))
+	case m.message != "":
+		lines = append(lines, styleYellow+fit(m.message, width)+styleReset)
+	default:
+		lines = append(lines, styleDim+fit(listHints, width)+styleReset)
+	}
+	return lines
+}
+
+// renderRow renders a group header or a finding of the list
+func (m *Model) renderRow(i, width int) string {
+	r := m.rows[i]
+	g := m.groups[r.group]
+
+	var text, style string
+	if r.item < 0 {
+		arrow := "▾"
+		if m.collapsed[g.rule] {
+			arrow = "▸"
+		}
+		selected, total := m.counts(m.visibleItems(r.group))
+		text = fmt.Sprintf("%s %s  %d/%d selected  %s", arrow, g.rule, selected, total, g.severity)
+		style = styleBold
+	} else {
+		it := m.items[r.item]
+		mark := " "
+		if it.Selected {
+			mark = "x"
+		}
+		when := "-               "
+		if !it.Timestamp.IsZero() {
+			when = it.Timestamp.Local().Format("2006-01-02 15:04")
+		}
+		text = fmt.Sprintf("   [%s] %s  L%-5d %s", mark, when, it.Finding.Line, strings.TrimSpace(m.line(it.Finding.Line)))
+	}
+
+	if i == m.cursor {
+		style += styleReverse
+	}
+	return style + fit(text, width) + styleReset
+}
+
+// renderContext renders the lines around the finding under the cursor
+func (m *Model) renderContext(width, height int) []string {
+	lines := make([]string, height)
+	if len(m.rows) == 0 {
+		return lines
+	}
+
+	r := m.rows[m.cursor]
+	item := r.item
+	if item < 0 {
+		items := m.visibleItems(r.group)
+		if len(items) == 0 {
+			return lines
+		}
+		item = items[0]
+	}
+
+	current := m.items[item].Finding.Line
+	first := current - height/2
+	for i := range lines {
+		n := first + i
+		if n < 1 || n > len(m.masked) {
+			continue
+		}
+		text := fmt.Sprintf("  %5d  %s", n, m.masked[n-1])
+		if n == current {
+			lines[i] = styleBold + fit(">"+text[1:], width) + styleReset
+		} else {
+			lines[i] = fit(text, width)
+		}
+	}
+	return lines
+}
+
+func (m *Model) viewDiff(width, height int) []string {
+	selected := m.Selected()
+
+	var body []string
+	if len(selected) == 0 {
+		body = append(body, "  No finding selected")
+	}
+
+	// Group the selected findings by line
+	for i := 0; i < len(selected); {
+		n := selected[i].Line
+		var onLine []scanner.Finding
+		for ; i < len(selected) && selected[i].Line == n; i++ {
+			f := selected[i]
+			f.Line = 1
+			onLine = append(onLine, f)
+		}
+		body = append(body,
+			styleDim+fit(fmt.Sprintf("@@ line %d", n), width)+styleReset,
+			styleRed+fit("- "+m.line(n), width)+styleReset,
+			styleGreen+fit("+ "+m.sanitized(n, onLine), width)+styleReset,
+			"")
+	}
+
+	lines := make([]string, 0, height)
+	title := fmt.Sprintf(" Diff preview  %d redaction(s)  %s", len(selected), m.file)
+	lines = append(lines, styleReverse+fit(title, width)+styleReset)
+
+	page := height - 2
+	if last := len(body) - page; m.scroll > last {
+		m.scroll = last
+	}
+	if m.scroll < 0 {
+		m.scroll = 0
+	}
+	for i := m.scroll; i < m.scroll+page; i++ {
+		if i < len(body) {
+			lines = append(lines, body[i])
+		} else {
+			lines = append(lines, "")
+		}
+	}
+
+	if m.message != "" {
+		lines = append(lines, styleYellow+fit(m.message, width)+styleReset)
+	} else {
+		lines = append(lines, styleDim+fit("w write  esc back  ↑↓ scroll", width)+styleReset)
+	}
+	return lines
+}
+
+// sanitized returns a line with the selected findings redacted and the
+// other secrets of the line masked
+func (m *Model) sanitized(n int, selected []scanner.Finding) string {
+	if n < 1 || n > len(m.raw) {
+		return ""
+	}
+	line := m.sanitize(m.raw[n-1], selected)
+	if line == "" && m.raw[n-1] != "" {
+		return "(entry deleted)"
+	}
+
+	for _, it := range m.items {
+		if it.Finding.Line == n && !it.Selected {
+			line = sanitizer.Preview(line, it.Finding)
+		}
+	}
+	return line
+}
+
+func (m *Model) viewHelp(width, height int) []string {
+	lines := []string{styleReverse + fit(" history-sanitizer help", width) + styleReset}
+	for _, text := range helpText {
+		if len(lines) == height {
+			break
+		}
+		lines = append(lines, fit(text, width))
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// fit pads or truncates text to width columns. Control characters, which
+// history lines may contain, are replaced so they cannot drive the
+// terminal.
+func fit(text string, width int) string {
+	runes := make([]rune, 0, width)
+	for _, r := range text {
+		switch {
+		case r == '\t':
+			r = ' '
+		case unicode.IsControl(r):
+			r = '?'
+		}
+		runes = append(runes, r)
+	}
+	if len(runes) > width {
+		runes = append(runes[:width-1], '…')
+	}
+	return string(runes) + strings.Repeat(" ", width-len(runes))
+}
@@ -0,0 +1,69 @@
+package vault
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// blob is the self-describing JSON layout of data sealed with Seal
+type blob struct {
+	Version    int       `json:"version"`
+	KDF        kdfParams `json:"kdf"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+// Seal encrypts data with a key derived from passphrase, using the same
+// scheme as the vault, for storing whole files such as history backups
+func Seal(data, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	b := blob{
+		Version: formatVersion,
+		KDF:     kdfParams{Name: "scrypt", Salt: salt, N: scryptN, R: scryptR, P: scryptP},
+	}
+
+	aead, err := newAEAD(passphrase, b.KDF)
+	if err != nil {
+		return nil, err
+	}
+	b.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(b.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	b.Ciphertext = aead.Seal(nil, b.Nonce, data, nil)
+
+	return json.Marshal(b)
+}
+
+// Unseal decrypts data produced by Seal
+func Unseal(sealed, passphrase []byte) ([]byte, error) {
+	var b blob
+	if err := json.Unmarshal(sealed, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted data: %w", err)
+	}
+	if b.Version != formatVersion || b.KDF.Name != "scrypt" {
+		return nil, errors.New("unsupported encryption format")
+	}
+
+	aead, err := newAEAD(passphrase, b.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce in encrypted data")
+	}
+	data, err := aead.Open(nil, b.Nonce, b.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return data, nil
+}
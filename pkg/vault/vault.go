@@ -0,0 +1,217 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/arnac-io/history-sanitizer/pkg/safefile"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	formatVersion = 1
+	keySize       = 32
+	saltSize      = 16
+
+	// scrypt cost parameters recommended for interactive use
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	// verifierText is encrypted with the derived key to detect a wrong
+	// passphrase before any secret is decrypted
+	verifierText = "history-sanitizer vault"
+)
+
+// ErrWrongPassphrase is returned when the passphrase does not open the vault
+var ErrWrongPassphrase = errors.New("wrong passphrase for vault")
+
+// ErrNotFound is returned when a placeholder has no entry in the vault
+var ErrNotFound = errors.New("placeholder not found in vault")
+
+// Entry is an encrypted secret stored under its placeholder
+type Entry struct {
+	Rule       string    `json:"rule"`
+	Created    time.Time `json:"created"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+// kdfParams records how the vault key is derived from the passphrase
+type kdfParams struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// file is the on-disk JSON layout of a vault
+type file struct {
+	Version  int              `json:"version"`
+	KDF      kdfParams        `json:"kdf"`
+	Verifier Entry            `json:"verifier"`
+	Entries  map[string]Entry `json:"entries"`
+}
+
+// Vault stores redacted secrets encrypted with AES-256-GCM under a key
+// derived from a passphrase with scrypt, so sanitized commands can be
+// recovered later by whoever knows the passphrase
+type Vault struct {
+	path string
+	aead cipher.AEAD
+	data file
+}
+
+// Open opens the vault at path with the passphrase, creating a new empty
+// vault if the file does not exist yet
+func Open(path string, passphrase []byte) (*Vault, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("vault passphrase must not be empty")
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return create(path, passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	v := &Vault{path: path}
+	if err := json.Unmarshal(raw, &v.data); err != nil {
+		return nil, fmt.Errorf("failed to parse vault %s: %w", path, err)
+	}
+	if v.data.Version != formatVersion || v.data.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported vault format in %s", path)
+	}
+	if v.data.Entries == nil {
+		v.data.Entries = make(map[string]Entry)
+	}
+
+	if v.aead, err = newAEAD(passphrase, v.data.KDF); err != nil {
+		return nil, err
+	}
+	plain, err := v.open(v.data.Verifier, "")
+	if err != nil || plain != verifierText {
+		return nil, ErrWrongPassphrase
+	}
+	return v, nil
+}
+
+// create initializes a new vault with a random salt
+func create(path string, passphrase []byte) (*Vault, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	v := &Vault{
+		path: path,
+		data: file{
+			Version: formatVersion,
+			KDF:     kdfParams{Name: "scrypt", Salt: salt, N: scryptN, R: scryptR, P: scryptP},
+			Entries: make(map[string]Entry),
+		},
+	}
+
+	var err error
+	if v.aead, err = newAEAD(passphrase, v.data.KDF); err != nil {
+		return nil, err
+	}
+	if v.data.Verifier, err = v.seal(verifierText, "", ""); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// newAEAD derives the vault key and returns the AES-GCM cipher
+func newAEAD(passphrase []byte, kdf kdfParams) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, kdf.Salt, kdf.N, kdf.R, kdf.P, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts a secret, binding it to its placeholder
+func (v *Vault) seal(secret, placeholder, rule string) (Entry, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Entry{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return Entry{
+		Rule:       rule,
+		Created:    time.Now().UTC(),
+		Nonce:      nonce,
+		Ciphertext: v.aead.Seal(nil, nonce, []byte(secret), []byte(placeholder)),
+	}, nil
+}
+
+// open decrypts an entry stored under placeholder
+func (v *Vault) open(e Entry, placeholder string) (string, error) {
+	plain, err := v.aead.Open(nil, e.Nonce, e.Ciphertext, []byte(placeholder))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", placeholder, err)
+	}
+	return string(plain), nil
+}
+
+// Put stores the secret that was redacted into placeholder
+func (v *Vault) Put(placeholder, secret, rule string) error {
+	e, err := v.seal(secret, placeholder, rule)
+	if err != nil {
+		return err
+	}
+	v.data.Entries[placeholder] = e
+	return nil
+}
+
+// Get returns the secret that was redacted into placeholder
+func (v *Vault) Get(placeholder string) (string, error) {
+	e, ok := v.data.Entries[placeholder]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v.open(e, placeholder)
+}
+
+// Rule returns the rule that detected the secret behind placeholder
+func (v *Vault) Rule(placeholder string) string {
+	return v.data.Entries[placeholder].Rule
+}
+
+// Placeholders returns the placeholders stored in the vault, sorted
+func (v *Vault) Placeholders() []string {
+	placeholders := make([]string, 0, len(v.data.Entries))
+	for p := range v.data.Entries {
+		placeholders = append(placeholders, p)
+	}
+	sort.Strings(placeholders)
+	return placeholders
+}
+
+// Save writes the vault back to disk with owner-only permissions
+func (v *Vault) Save() error {
+	raw, err := json.MarshalIndent(v.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode vault: %w", err)
+	}
+
+	// Replace the file atomically so a crash never leaves a truncated vault
+	if err := safefile.WriteFile(v.path, raw, 0600); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+//go:build linux
+
+package watch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// dirEvents are the events of the watched directories that can change a
+// file in them: writes, and files created or renamed over the old one
+const dirEvents = unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_MOVED_TO
+
+// startInotify watches the directories of the files rather than the files
+// themselves, since an inotify watch follows the inode and a history
+// replaced by a rename would no longer be watched
+func (w *Watcher) startInotify(paths []string) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("inotify: %w", err)
+	}
+
+	dirs := make(map[int32]string)
+	watched := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		watched[path] = true
+		dir := filepath.Dir(path)
+		wd, err := unix.InotifyAddWatch(fd, dir, dirEvents)
+		if err != nil {
+			unix.Close(fd)
+			return fmt.Errorf("inotify: watching %s: %w", dir, err)
+		}
+		dirs[int32(wd)] = dir
+	}
+
+	// A non-blocking descriptor goes through the runtime poller, so
+	// closing the file interrupts a pending read
+	f := os.NewFile(uintptr(fd), "inotify")
+	w.stop = f.Close
+	w.Method = MethodInotify
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.readInotify(f, dirs, watched)
+	}()
+	return nil
+}
+
+// readInotify turns the events of the watched directories into changes of
+// the watched files
+func (w *Watcher) readInotify(f *os.File, dirs map[int32]string, watched map[string]bool) {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			select {
+			case <-w.done:
+			default:
+				w.fail(fmt.Errorf("inotify: %w", err))
+			}
+			return
+		}
+
+		for off := 0; off+unix.SizeofInotifyEvent <= n; {
+			wd := int32(binary.NativeEndian.Uint32(buf[off:]))
+			mask := binary.NativeEndian.Uint32(buf[off+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[off+12:]))
+			name := buf[off+unix.SizeofInotifyEvent : off+unix.SizeofInotifyEvent+nameLen]
+			off += unix.SizeofInotifyEvent + nameLen
+
+			// Events were lost: treat every file as changed
+			if mask&unix.IN_Q_OVERFLOW != 0 {
+				for path := range watched {
+					if !w.notify(path) {
+						return
+					}
+				}
+				continue
+			}
+
+			dir, ok := dirs[wd]
+			if !ok {
+				continue
+			}
+			// The name is padded with NUL bytes
+			if i := bytes.IndexByte(name, 0); i >= 0 {
+				name = name[:i]
+			}
+			path := filepath.Join(dir, string(name))
+			if watched[path] && !w.notify(path) {
+				return
+			}
+		}
+	}
+}
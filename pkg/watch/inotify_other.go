@@ -0,0 +1,10 @@
+//go:build !linux
+
+package watch
+
+import "errors"
+
+// startInotify always fails outside Linux, so files are polled
+func (w *Watcher) startInotify(paths []string) error {
+	return errors.New("inotify is only available on Linux")
+}
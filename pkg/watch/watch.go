@@ -0,0 +1,201 @@
+// Package watch reports changes to a set of files, through inotify on Linux
+// and by polling their metadata elsewhere
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Change detection methods
+const (
+	MethodInotify = "inotify"
+	MethodPolling = "polling"
+)
+
+const (
+	// DefaultInterval is how often files are polled
+	DefaultInterval = 2 * time.Second
+	// DefaultDelay is how long a file must stay unchanged before its
+	// change is reported, so a shell writing several entries in a row
+	// causes a single report
+	DefaultDelay = 500 * time.Millisecond
+)
+
+// Options configures a Watcher
+type Options struct {
+	// Interval is the polling interval, DefaultInterval when zero
+	Interval time.Duration
+	// Delay is how long a file must stay unchanged before it is reported,
+	// DefaultDelay when zero
+	Delay time.Duration
+	// Poll forces polling even when inotify is available
+	Poll bool
+}
+
+// Watcher reports the files that changed. A file replaced by a rename, as
+// zsh and atomic writers do, counts as changed.
+type Watcher struct {
+	// Changes receives the path of each file that changed, once it stayed
+	// unchanged for the delay
+	Changes <-chan string
+	// Errors receives the errors that stopped change detection
+	Errors <-chan error
+	// Method is MethodInotify or MethodPolling
+	Method string
+
+	raw     chan string
+	changes chan string
+	errors  chan error
+	done    chan struct{}
+	stop    func() error
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// New watches the files at paths, with inotify when available and polling
+// otherwise. The paths are made absolute; missing files are reported once
+// they are created.
+func New(paths []string, opts Options) (*Watcher, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Delay <= 0 {
+		opts.Delay = DefaultDelay
+	}
+
+	abs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		a, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		abs = append(abs, a)
+	}
+
+	w := &Watcher{
+		raw:     make(chan string),
+		changes: make(chan string),
+		errors:  make(chan error, 1),
+		done:    make(chan struct{}),
+	}
+	w.Changes = w.changes
+	w.Errors = w.errors
+
+	// Fall back to polling when inotify is missing or out of watches
+	if opts.Poll || w.startInotify(abs) != nil {
+		w.startPolling(abs, opts.Interval)
+	}
+
+	w.wg.Add(1)
+	go w.debounce(opts.Delay)
+	return w, nil
+}
+
+// Close stops watching. Changes is not closed, so a pending receive must
+// also select on the caller's own stop condition.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		if w.stop != nil {
+			err = w.stop()
+		}
+		w.wg.Wait()
+	})
+	return err
+}
+
+// notify passes a changed path to the debouncer
+func (w *Watcher) notify(path string) bool {
+	select {
+	case w.raw <- path:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// fail reports an error that stopped change detection
+func (w *Watcher) fail(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+// debounce reports the changed paths once no change happened for delay
+func (w *Watcher) debounce(delay time.Duration) {
+	defer w.wg.Done()
+
+	pending := make(map[string]bool)
+	var fire <-chan time.Time
+	for {
+		select {
+		case <-w.done:
+			return
+		case path := <-w.raw:
+			pending[path] = true
+			fire = time.After(delay)
+		case <-fire:
+			fire = nil
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			pending = make(map[string]bool)
+			for _, path := range paths {
+				select {
+				case w.changes <- path:
+				case <-w.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// startPolling compares the metadata of the files every interval
+func (w *Watcher) startPolling(paths []string, interval time.Duration) {
+	w.Method = MethodPolling
+
+	last := make(map[string]os.FileInfo, len(paths))
+	for _, path := range paths {
+		last[path], _ = os.Stat(path)
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+			}
+			for _, path := range paths {
+				info, _ := os.Stat(path)
+				prev := last[path]
+				last[path] = info
+				if changed(prev, info) && !w.notify(path) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// changed reports whether a file was modified, replaced or created between
+// two stats. A file being removed is not a change.
+func changed(prev, cur os.FileInfo) bool {
+	if prev == nil || cur == nil {
+		return cur != nil
+	}
+	return !os.SameFile(prev, cur) || prev.Size() != cur.Size() || !prev.ModTime().Equal(cur.ModTime())
+}
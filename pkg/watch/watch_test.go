@@ -0,0 +1,148 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+var testOptions = Options{Interval: 20 * time.Millisecond, Delay: 50 * time.Millisecond}
+
+// next returns the next reported change, failing after a timeout
+func next(t *testing.T, w *Watcher) string {
+	t.Helper()
+	select {
+	case path := <-w.Changes:
+		return path
+	case err := <-w.Errors:
+		t.Fatalf("watcher failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a change")
+	}
+	return ""
+}
+
+// quiet checks that no change is reported for a while
+func quiet(t *testing.T, w *Watcher) {
+	t.Helper()
+	select {
+	case path := <-w.Changes:
+		t.Errorf("unexpected change of %s", path)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func appendLine(t *testing.T, path, line string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testWatcher(t *testing.T, opts Options) (*Watcher, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	history := filepath.Join(dir, ".zsh_history")
+	other := filepath.Join(dir, "other")
+	for _, path := range []string{history, other} {
+		if err := os.WriteFile(path, []byte("ls\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := New([]string{history}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w, history, other
+}
+
+func testChanges(t *testing.T, w *Watcher, history, other string) {
+	// A burst of writes is reported once
+	for i := 0; i < 5; i++ {
+		appendLine(t, history, "echo hi")
+	}
+	if got := next(t, w); got != history {
+		t.Errorf("got change of %s, want %s", got, history)
+	}
+	quiet(t, w)
+
+	// Other files of the directory are ignored
+	appendLine(t, other, "echo hi")
+	quiet(t, w)
+
+	// A file replaced by a rename is still watched afterwards
+	tmp := history + ".new"
+	if err := os.WriteFile(tmp, []byte("ls\npwd\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, history); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(t, w); got != history {
+		t.Errorf("got change of %s, want %s", got, history)
+	}
+	appendLine(t, history, "echo again")
+	if got := next(t, w); got != history {
+		t.Errorf("got change of %s, want %s", got, history)
+	}
+}
+
+func TestWatcher_Polling(t *testing.T) {
+	opts := testOptions
+	opts.Poll = true
+	w, history, other := testWatcher(t, opts)
+	if w.Method != MethodPolling {
+		t.Fatalf("expected polling, got %s", w.Method)
+	}
+	testChanges(t, w, history, other)
+}
+
+func TestWatcher_Inotify(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("inotify is only available on Linux")
+	}
+	w, history, other := testWatcher(t, testOptions)
+	if w.Method != MethodInotify {
+		t.Fatalf("expected inotify, got %s", w.Method)
+	}
+	testChanges(t, w, history, other)
+}
+
+func TestWatcher_CreatedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fish_history")
+
+	opts := testOptions
+	opts.Poll = true
+	w, err := New([]string{path}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	if err := os.WriteFile(path, []byte("- cmd: ls\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(t, w); got != path {
+		t.Errorf("got change of %s, want %s", got, path)
+	}
+}
+
+func TestWatcher_Close(t *testing.T) {
+	w, history, _ := testWatcher(t, testOptions)
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Closing twice and writing afterwards are harmless
+	w.Close()
+	appendLine(t, history, "echo hi")
+}